@@ -0,0 +1,122 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Return a generated file with the given number of sections and properties
+// per section. Every tenth property has a preceding comment and every
+// seventh value contains an escape sequence.
+func syntheticFile(sections, properties int) string {
+	var buf strings.Builder
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&buf, "[host-%d.example.com]\n", i)
+		for j := 0; j < properties; j++ {
+			if j%10 == 0 {
+				fmt.Fprintf(&buf, "# property %d\n", j)
+			}
+			if j%7 == 0 {
+				fmt.Fprintf(&buf, "key%d = C:\\\\data\\\\%d\\tx\n", j, i)
+			} else {
+				fmt.Fprintf(&buf, "key%d = value %d of host %d\n", j, j, i)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+var (
+	smallFile = syntheticFile(10, 10)
+	largeFile = syntheticFile(1000, 50)
+)
+
+func benchmarkParseConfig(b *testing.B, input string) {
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewConfigFromString(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseConfigSmall(b *testing.B) {
+	benchmarkParseConfig(b, smallFile)
+}
+
+func BenchmarkParseConfigLarge(b *testing.B) {
+	benchmarkParseConfig(b, largeFile)
+}
+
+func BenchmarkParseDocumentLarge(b *testing.B) {
+	b.SetBytes(int64(len(largeFile)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewDocumentFromString(largeFile); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecoderLarge(b *testing.B) {
+	b.SetBytes(int64(len(largeFile)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := Walk(strings.NewReader(largeFile), nil, func(token *Token) error {
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWalkOneSection(b *testing.B) {
+	b.SetBytes(int64(len(largeFile)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := Walk(strings.NewReader(largeFile), nil, func(token *Token) error {
+			if token.Kind == SectionToken && token.Section != "host-999.example.com" {
+				return SkipSection
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConfigGet(b *testing.B) {
+	conf, _ := NewConfigFromString(largeFile)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := conf.Get("host-500.example.com", "key49"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConfigHasProperty(b *testing.B) {
+	conf, _ := NewConfigFromString(largeFile)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if conf.HasProperty("host-500.example.com", "missing") {
+			b.Fatal("unexpected property")
+		}
+	}
+}
+
+func BenchmarkConfigString(b *testing.B) {
+	conf, _ := NewConfigFromString(largeFile)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = conf.String()
+	}
+}
@@ -0,0 +1,179 @@
+package ini
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A CaseFolding decides whether section and property names which differ only
+// in case, e.g. [Global] and [global], are treated as the same name.
+type CaseFolding int
+
+const (
+	// Names are compared exactly. This is the default.
+	CaseSensitive CaseFolding = iota
+	// The ASCII letters A to Z are treated like a to z. Other characters
+	// are compared exactly.
+	FoldASCII
+	// Names are compared under Unicode simple case folding, like
+	// strings.EqualFold does.
+	FoldUnicode
+)
+
+// Returns true if the names a and b are the same under the case folding.
+func (f CaseFolding) Equal(a, b string) bool {
+	switch f {
+	case FoldASCII:
+		return f.key(a) == f.key(b)
+	case FoldUnicode:
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// Return a string which is the same for all names which are equal under the
+// case folding, to be used as a map key.
+func (f CaseFolding) key(name string) string {
+	switch f {
+	case FoldASCII:
+		return strings.Map(func(r rune) rune {
+			if 'A' <= r && r <= 'Z' {
+				return r + 'a' - 'A'
+			}
+			return r
+		}, name)
+	case FoldUnicode:
+		return strings.Map(foldRune, name)
+	}
+	return name
+}
+
+// return the smallest rune which is equivalent to r under simple case folding
+func foldRune(r rune) rune {
+	smallest := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < smallest {
+			smallest = f
+		}
+	}
+	return smallest
+}
+
+// A spellings remembers the first spelling of each section and property name,
+// so that a Config uses it for all names which are equal under the case
+// folding.
+type spellings struct {
+	folding    CaseFolding
+	sections   map[string]string
+	properties map[string]map[string]string
+}
+
+func newSpellings(folding CaseFolding) *spellings {
+	return &spellings{
+		folding:    folding,
+		sections:   make(map[string]string),
+		properties: make(map[string]map[string]string)}
+}
+
+// Return the first spelling of the given section name.
+func (s *spellings) section(name string) string {
+	if s.folding == CaseSensitive {
+		return name
+	}
+	key := s.folding.key(name)
+	if first, ok := s.sections[key]; ok {
+		return first
+	}
+	s.sections[key] = name
+	s.properties[key] = make(map[string]string)
+	return name
+}
+
+// Return the first spelling of the given property name within the section.
+func (s *spellings) property(section, name string) string {
+	if s.folding == CaseSensitive {
+		return name
+	}
+	properties := s.properties[s.folding.key(section)]
+	key := s.folding.key(name)
+	if first, ok := properties[key]; ok {
+		return first
+	}
+	properties[key] = name
+	return name
+}
+
+// Return the name of the section which equals the given one under the case
+// folding. An exact match is preferred; if several names differ only in case,
+// the smallest of them is returned, so that the result does not depend on the
+// order of the map.
+func (c *Config) foldSection(section string, folding CaseFolding) (string, bool) {
+	if _, ok := (*c)[section]; ok || folding == CaseSensitive {
+		return section, ok
+	}
+	found, ok := "", false
+	for name := range *c {
+		if folding.Equal(name, section) && (!ok || name < found) {
+			found, ok = name, true
+		}
+	}
+	return found, ok
+}
+
+// Return the name of the property within the properties of a section, see
+// foldSection.
+func foldProperty(properties map[string]string, property string, folding CaseFolding) (string, bool) {
+	if _, ok := properties[property]; ok || folding == CaseSensitive {
+		return property, ok
+	}
+	found, ok := "", false
+	for name := range properties {
+		if folding.Equal(name, property) && (!ok || name < found) {
+			found, ok = name, true
+		}
+	}
+	return found, ok
+}
+
+// Returns true if the config contains a section whose name equals the given
+// one under the case folding, see ParseOptions.CaseFolding.
+func (c *Config) HasSectionFold(section string, folding CaseFolding) bool {
+	_, ok := c.foldSection(section, folding)
+	return ok
+}
+
+// Returns true if the section and the property exist under the case folding,
+// see HasSectionFold.
+func (c *Config) HasPropertyFold(section, property string, folding CaseFolding) bool {
+	_, err := c.GetFold(section, property, folding)
+	return err == nil
+}
+
+// Get the value of the passed property in the given section like Get, but
+// compare the names under the case folding, so that the names need not be
+// spelled like in the file.
+func (c *Config) GetFold(section, property string, folding CaseFolding) (string, error) {
+	name, ok := c.foldSection(section, folding)
+	if !ok {
+		return "", NoSectionError
+	}
+	spelling, ok := foldProperty((*c)[name], property, folding)
+	if !ok {
+		return "", NoPropertyError{property}
+	}
+	return (*c)[name][spelling], nil
+}
+
+// Set the given property in the given section like Set, but compare the names
+// under the case folding. An existing property keeps its spelling.
+func (c *Config) SetFold(section, property, value string, folding CaseFolding) error {
+	name, ok := c.foldSection(section, folding)
+	if !ok {
+		return NoSectionError
+	}
+	if existing, ok := foldProperty((*c)[name], property, folding); ok {
+		property = existing
+	}
+	(*c)[name][property] = value
+	return nil
+}
@@ -0,0 +1,127 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+const caseFoldingDocument = `[Global]
+Workgroup = HOME
+[global]
+workgroup = OFFICE
+Server String = samba
+[ΣΊΣΥΦΟΣ]
+Straße = 1
+`
+
+func TestCaseFoldingEqual(t *testing.T) {
+	cases := []struct {
+		folding  CaseFolding
+		a, b     string
+		expected bool
+	}{
+		{CaseSensitive, "Global", "global", false},
+		{CaseSensitive, "global", "global", true},
+		{FoldASCII, "Global", "gLOBAL", true},
+		{FoldASCII, "Ä", "ä", false},
+		{FoldUnicode, "Ä", "ä", true},
+		{FoldUnicode, "σίσυφος", "ΣΊΣΥΦΟΣ", true},
+		{FoldUnicode, "Kelvin", "Kelvin", true},
+		{FoldUnicode, "a", "b", false}}
+	for _, c := range cases {
+		if equal := c.folding.Equal(c.a, c.b); equal != c.expected {
+			t.Errorf("%d: expected Equal(%q, %q) to be %v", c.folding, c.a, c.b, c.expected)
+		}
+		if equal := c.folding.key(c.a) == c.folding.key(c.b); equal != c.expected {
+			t.Errorf("%d: expected the keys of %q and %q to be equal: %v", c.folding, c.a, c.b, c.expected)
+		}
+	}
+}
+
+func TestParseConfigCaseFolding(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(caseFoldingDocument), &ParseOptions{CaseFolding: FoldUnicode})
+	assertErrorIsNil(err, t)
+	expected := &Config{
+		"Global":  {"Workgroup": "OFFICE", "Server String": "samba"},
+		"ΣΊΣΥΦΟΣ": {"Straße": "1"}}
+	assertConfigMapsEqual(conf, expected, t)
+	conf, err = ParseConfig(strings.NewReader(caseFoldingDocument), nil)
+	assertErrorIsNil(err, t)
+	if len(*conf) != 3 {
+		t.Errorf("expected three sections without case folding, got %v", conf)
+	}
+	options := &ParseOptions{CaseFolding: FoldASCII, DuplicateSections: DuplicateError}
+	_, err = ParseConfig(strings.NewReader(caseFoldingDocument), options)
+	if err != (DuplicateDefinitionError{"Global", "", 1, 3}) {
+		t.Errorf("expected DuplicateDefinitionError, got %v", err)
+	}
+}
+
+func TestDocumentCaseFolding(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(caseFoldingDocument), &ParseOptions{CaseFolding: FoldUnicode})
+	assertErrorIsNil(err, t)
+	expectDocumentString(caseFoldingDocument, doc, t)
+	if !doc.HasSection("GLOBAL") || !doc.HasProperty("σίσυφος", "STRAßE") {
+		t.Error("expected the lookups to ignore case")
+	}
+	// simple case folding does not map ß to ss
+	if doc.HasProperty("σίσυφος", "STRASSE") {
+		t.Error("expected STRASSE to be a different property")
+	}
+	value, err := doc.Get("GLOBAL", "WORKGROUP")
+	assertErrorIsNil(err, t)
+	expectValue("OFFICE", value, t)
+	expectValues([]string{"Global", "ΣΊΣΥΦΟΣ"}, doc.GetSections(), t)
+	items, _ := doc.GetItems("global")
+	if len(items) != 2 || items[0].Property != "Workgroup" {
+		t.Errorf("expected the items Workgroup and Server String, got %v", items)
+	}
+	assertErrorIsNil(doc.Set("GLOBAL", "server string", "files"), t)
+	if err := doc.AddSection("global"); err != DuplicateSectionError {
+		t.Errorf("expected DuplicateSectionError, got %v", err)
+	}
+	assertErrorIsNil(doc.RemoveProperty("global", "WorkGroup"), t)
+	expected := "[Global]\n[global]\nServer String = files\n[ΣΊΣΥΦΟΣ]\nStraße = 1\n"
+	expectDocumentString(expected, doc, t)
+	assertConfigMapsEqual(doc.Config(), &Config{
+		"Global":  {"Server String": "files"},
+		"ΣΊΣΥΦΟΣ": {"Straße": "1"}}, t)
+}
+
+func TestDocumentCaseSensitiveByDefault(t *testing.T) {
+	doc, _ := NewDocumentFromString(caseFoldingDocument)
+	if doc.HasSection("GLOBAL") {
+		t.Error("expected the lookups to be case-sensitive")
+	}
+	expectValues([]string{"Global", "global", "ΣΊΣΥΦΟΣ"}, doc.GetSections(), t)
+}
+
+func TestConfigFoldLookups(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader("[Global]\nKey = 1\n"), &ParseOptions{CaseFolding: FoldASCII})
+	assertErrorIsNil(err, t)
+	if !conf.HasSectionFold("global", FoldASCII) || conf.HasSectionFold("global", CaseSensitive) {
+		t.Error("expected the section to be found under case folding only")
+	}
+	if !conf.HasPropertyFold("GLOBAL", "key", FoldASCII) || conf.HasPropertyFold("global", "missing", FoldASCII) {
+		t.Error("expected only the existing property to be found")
+	}
+	value, err := conf.GetFold("global", "key", FoldASCII)
+	assertErrorIsNil(err, t)
+	expectValue("1", value, t)
+	if _, err := conf.GetFold("other", "key", FoldASCII); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	if _, err := conf.GetFold("global", "other", FoldASCII); err != (NoPropertyError{"other"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+	assertErrorIsNil(conf.SetFold("GLOBAL", "KEY", "2", FoldASCII), t)
+	assertErrorIsNil(conf.SetFold("global", "New", "3", FoldASCII), t)
+	assertConfigMapsEqual(conf, &Config{"Global": {"Key": "2", "New": "3"}}, t)
+	if err := conf.SetFold("other", "key", "1", FoldASCII); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	conf = &Config{"ÄRGER": {"x": "1"}}
+	if !conf.HasSectionFold("ärger", FoldUnicode) || conf.HasSectionFold("ärger", FoldASCII) {
+		t.Error("expected only Unicode folding to match non-ASCII letters")
+	}
+}
@@ -0,0 +1,253 @@
+// Command ini queries and edits ini files from the command line.
+//
+// Usage:
+//
+//	ini get FILE SECTION PROPERTY
+//	ini set [-i] FILE SECTION PROPERTY VALUE
+//	ini unset [-i] FILE SECTION PROPERTY
+//	ini sections FILE
+//	ini keys FILE SECTION
+//	ini dump FILE
+//	ini validate FILE...
+//	ini fmt [-i] FILE
+//
+// FILE may be - to read from the standard input. Commands which change the
+// file write the result to the standard output, unless -i is given, in which
+// case the file is edited in place. Only the changed lines are rewritten;
+// comments, blank lines and the order of the file are kept.
+//
+// The exit status is 0 on success, 1 if the file cannot be read or parsed, 2
+// on wrong usage, 3 if the section does not exist and 4 if the property does
+// not exist.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/derdon/ini"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNoSection
+	exitNoProperty
+)
+
+const usage = `usage:
+	ini get FILE SECTION PROPERTY
+	ini set [-i] FILE SECTION PROPERTY VALUE
+	ini unset [-i] FILE SECTION PROPERTY
+	ini sections FILE
+	ini keys FILE SECTION
+	ini dump FILE
+	ini validate FILE...
+	ini fmt [-i] FILE
+`
+
+type command struct {
+	// number of positional arguments including FILE; -1 means at least one
+	nargs int
+	// whether the command accepts -i
+	edits bool
+	run   func(doc *ini.Document, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"get":      {3, false, get},
+	"set":      {4, true, set},
+	"unset":    {3, true, unset},
+	"sections": {1, false, sections},
+	"keys":     {2, false, keys},
+	"dump":     {1, false, dump},
+	"validate": {-1, false, nil},
+	"fmt":      {1, true, format},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "ini: unknown command %q\n%s", name, usage)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("ini "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	inPlace := false
+	if cmd.edits {
+		flags.BoolVar(&inPlace, "i", false, "edit the file in place")
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	args = flags.Args()
+	if (cmd.nargs == -1 && len(args) == 0) || (cmd.nargs != -1 && len(args) != cmd.nargs) {
+		fmt.Fprintf(stderr, "ini %s: wrong number of arguments\n%s", name, usage)
+		return exitUsage
+	}
+	if name == "validate" {
+		return validate(args, stdin, stdout, stderr)
+	}
+	filename := args[0]
+	if inPlace && filename == "-" {
+		fmt.Fprintf(stderr, "ini %s: cannot edit the standard input in place\n", name)
+		return exitUsage
+	}
+	doc, err := readDocument(filename, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "ini: %s: %v\n", filename, err)
+		return exitError
+	}
+	output := stdout
+	if inPlace {
+		output = io.Discard
+	}
+	if err := cmd.run(doc, args[1:], output); err != nil {
+		fmt.Fprintf(stderr, "ini %s: %v\n", name, err)
+		return exitCode(err)
+	}
+	if inPlace {
+		if err := writeFile(filename, doc.String()); err != nil {
+			fmt.Fprintf(stderr, "ini: %v\n", err)
+			return exitError
+		}
+	}
+	return exitOK
+}
+
+func exitCode(err error) int {
+	var noProperty ini.NoPropertyError
+	switch {
+	case errors.Is(err, ini.NoSectionError):
+		return exitNoSection
+	case errors.As(err, &noProperty):
+		return exitNoProperty
+	}
+	return exitError
+}
+
+func readDocument(filename string, stdin io.Reader) (*ini.Document, error) {
+	if filename == "-" {
+		return ini.NewDocumentFromByteReader(bufio.NewReader(stdin))
+	}
+	return ini.NewDocumentFromFilename(filename)
+}
+
+// Replace the content of the file atomically by writing to a temporary file
+// in the same directory and renaming it. The permissions of the file are
+// kept.
+func writeFile(filename, content string) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func get(doc *ini.Document, args []string, stdout io.Writer) error {
+	value, err := doc.Get(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, value)
+	return nil
+}
+
+// set creates the section if it does not exist yet
+func set(doc *ini.Document, args []string, stdout io.Writer) error {
+	section, property, value := args[0], args[1], args[2]
+	if !doc.HasSection(section) {
+		doc.AddSection(section)
+	}
+	if err := doc.Set(section, property, value); err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, doc)
+	return nil
+}
+
+func unset(doc *ini.Document, args []string, stdout io.Writer) error {
+	if err := doc.RemoveProperty(args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, doc)
+	return nil
+}
+
+func sections(doc *ini.Document, args []string, stdout io.Writer) error {
+	for _, section := range doc.GetSections() {
+		fmt.Fprintln(stdout, section)
+	}
+	return nil
+}
+
+func keys(doc *ini.Document, args []string, stdout io.Writer) error {
+	items, err := doc.GetItems(args[0])
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		fmt.Fprintln(stdout, item.Property)
+	}
+	return nil
+}
+
+// dump prints one line per property in the form SECTION<tab>PROPERTY<tab>VALUE
+func dump(doc *ini.Document, args []string, stdout io.Writer) error {
+	for _, section := range doc.GetSections() {
+		items, _ := doc.GetItems(section)
+		for _, item := range items {
+			fmt.Fprintf(stdout, "%s\t%s\t%s\n", section, item.Property, item.Value)
+		}
+	}
+	return nil
+}
+
+// format rewrites the file in the canonical form of ini.Format
+func format(doc *ini.Document, args []string, stdout io.Writer) error {
+	doc.Format(&ini.DefaultFormatOptions)
+	fmt.Fprint(stdout, doc)
+	return nil
+}
+
+func validate(filenames []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	status := exitOK
+	for _, filename := range filenames {
+		if _, err := readDocument(filename, stdin); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", filename, err)
+			status = exitError
+		}
+	}
+	return status
+}
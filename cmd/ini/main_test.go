@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const exampleFile = `# managed by hand
+[server]
+host = localhost
+port=80
+
+[empty]
+`
+
+func runCommand(t *testing.T, stdin string, args ...string) (status int, stdout, stderr string) {
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	status = run(args, strings.NewReader(stdin), outBuf, errBuf)
+	return status, outBuf.String(), errBuf.String()
+}
+
+func expectOutput(t *testing.T, expectedStatus int, expectedStdout string, status int, stdout, stderr string) {
+	t.Helper()
+	if status != expectedStatus {
+		t.Errorf("expected exit status %d, got %d (stderr: %q)", expectedStatus, status, stderr)
+	}
+	if stdout != expectedStdout {
+		t.Errorf("expected output %q, got %q", expectedStdout, stdout)
+	}
+}
+
+func TestGet(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "get", "-", "server", "port")
+	expectOutput(t, exitOK, "80\n", status, stdout, stderr)
+}
+
+func TestGetExitCodes(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "get", "-", "nosection", "port")
+	expectOutput(t, exitNoSection, "", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "get", "-", "server", "noproperty")
+	expectOutput(t, exitNoProperty, "", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, "[s]\nbroken", "get", "-", "s", "a")
+	expectOutput(t, exitError, "", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "get", "-", "server")
+	expectOutput(t, exitUsage, "", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "frobnicate")
+	expectOutput(t, exitUsage, "", status, stdout, stderr)
+}
+
+func TestSetPrintsWholeFile(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "set", "-", "server", "port", "8080")
+	expected := strings.Replace(exampleFile, "port=80", "port = 8080", 1)
+	expectOutput(t, exitOK, expected, status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "set", "-", "new", "a", "b")
+	expectOutput(t, exitOK, exampleFile+"[new]\na = b\n", status, stdout, stderr)
+}
+
+func TestUnset(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "unset", "-", "server", "host")
+	expected := strings.Replace(exampleFile, "host = localhost\n", "", 1)
+	expectOutput(t, exitOK, expected, status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "unset", "-", "empty", "host")
+	expectOutput(t, exitNoProperty, "", status, stdout, stderr)
+}
+
+func TestSetInPlace(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(filename, []byte(exampleFile), 0640); err != nil {
+		t.Fatal(err)
+	}
+	status, stdout, stderr := runCommand(t, "", "set", "-i", filename, "server", "host", "example.org")
+	expectOutput(t, exitOK, "", status, stdout, stderr)
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(exampleFile, "localhost", "example.org", 1)
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+	info, _ := os.Stat(filename)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640, got %v", info.Mode().Perm())
+	}
+	status, stdout, stderr = runCommand(t, "", "set", "-i", "-", "server", "host", "x")
+	expectOutput(t, exitUsage, "", status, stdout, stderr)
+}
+
+func TestSetInPlaceKeepsLineEndings(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.ini")
+	original := "[server]\r\nhost = localhost\r\nport=80"
+	if err := os.WriteFile(filename, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	status, stdout, stderr := runCommand(t, "", "set", "-i", filename, "server", "host", "example.org")
+	expectOutput(t, exitOK, "", status, stdout, stderr)
+	content, _ := os.ReadFile(filename)
+	expected := "[server]\r\nhost = example.org\r\nport=80"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+	mixed := "[server]\r\n# bind address\nhost = localhost\nport=80\r\n"
+	if err := os.WriteFile(filename, []byte(mixed), 0640); err != nil {
+		t.Fatal(err)
+	}
+	status, stdout, stderr = runCommand(t, "", "set", "-i", filename, "server", "port", "8080")
+	expectOutput(t, exitOK, "", status, stdout, stderr)
+	content, _ = os.ReadFile(filename)
+	expected = "[server]\r\n# bind address\nhost = localhost\nport = 8080\r\n"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestSectionsKeysAndDump(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "sections", "-")
+	expectOutput(t, exitOK, "server\nempty\n", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "keys", "-", "server")
+	expectOutput(t, exitOK, "host\nport\n", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "keys", "-", "nosection")
+	expectOutput(t, exitNoSection, "", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, exampleFile, "dump", "-")
+	expectOutput(t, exitOK, "server\thost\tlocalhost\nserver\tport\t80\n", status, stdout, stderr)
+}
+
+func TestValidate(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "validate", "-")
+	expectOutput(t, exitOK, "", status, stdout, stderr)
+	status, stdout, stderr = runCommand(t, "[s]\na = b\nbroken\n", "validate", "-")
+	expectOutput(t, exitError, "", status, stdout, stderr)
+	if expected := "-: line 3: missing equal sign\n"; stderr != expected {
+		t.Errorf("expected %q, got %q", expected, stderr)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	status, stdout, stderr := runCommand(t, exampleFile, "fmt", "-")
+	expected := strings.Replace(exampleFile, "port=80", "port = 80", 1)
+	expectOutput(t, exitOK, expected, status, stdout, stderr)
+}
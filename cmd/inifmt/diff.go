@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// number of unchanged lines shown around each change
+const diffContext = 3
+
+type diffLine struct {
+	// ' ' for unchanged, '-' for removed and '+' for added lines
+	kind byte
+	text string
+	// line numbers in the old and the new file, starting at 0
+	oldLine, newLine int
+}
+
+// Compute the line based differences between a and b with the linear space
+// variant of Myers' algorithm, which needs O((N+M)D) time for N and M lines
+// and D differences. Within each block of changes, the removed lines come
+// before the added ones.
+func diffLines(a, b []string) []diffLine {
+	d := &differ{a: a, b: b}
+	d.diff(0, len(a), 0, len(b))
+	for start := 0; start < len(d.lines); start++ {
+		end := start
+		for end < len(d.lines) && d.lines[end].kind != ' ' {
+			end++
+		}
+		block := d.lines[start:end]
+		sort.SliceStable(block, func(i, j int) bool {
+			return block[i].kind == '-' && block[j].kind == '+'
+		})
+		start = end
+	}
+	i, j := 0, 0
+	for k := range d.lines {
+		d.lines[k].oldLine, d.lines[k].newLine = i, j
+		if d.lines[k].kind != '+' {
+			i++
+		}
+		if d.lines[k].kind != '-' {
+			j++
+		}
+	}
+	return d.lines
+}
+
+// a differ collects the differences between a and b, see diffLines
+type differ struct {
+	a, b  []string
+	lines []diffLine
+}
+
+func (d *differ) add(kind byte, text string) {
+	d.lines = append(d.lines, diffLine{kind: kind, text: text})
+}
+
+// find the differences between a[aStart:aEnd] and b[bStart:bEnd]
+func (d *differ) diff(aStart, aEnd, bStart, bEnd int) {
+	for aStart < aEnd && bStart < bEnd && d.a[aStart] == d.b[bStart] {
+		d.add(' ', d.a[aStart])
+		aStart++
+		bStart++
+	}
+	suffix := 0
+	for aStart < aEnd-suffix && bStart < bEnd-suffix && d.a[aEnd-suffix-1] == d.b[bEnd-suffix-1] {
+		suffix++
+	}
+	aEnd, bEnd = aEnd-suffix, bEnd-suffix
+	switch {
+	case aStart == aEnd:
+		for _, line := range d.b[bStart:bEnd] {
+			d.add('+', line)
+		}
+	case bStart == bEnd:
+		for _, line := range d.a[aStart:aEnd] {
+			d.add('-', line)
+		}
+	default:
+		xStart, yStart, xEnd, yEnd := d.middleSnake(aStart, aEnd, bStart, bEnd)
+		d.diff(aStart, xStart, bStart, yStart)
+		for _, line := range d.a[xStart:xEnd] {
+			d.add(' ', line)
+		}
+		d.diff(xEnd, aEnd, yEnd, bEnd)
+	}
+	for _, line := range d.a[aEnd : aEnd+suffix] {
+		d.add(' ', line)
+	}
+}
+
+// Return the start and the end of the middle snake of a shortest edit script
+// of a[aStart:aEnd] and b[bStart:bEnd], i.e. a run of unchanged lines which
+// splits the script into two halves of about the same length. forward and
+// backward hold the furthest reaching path on each diagonal of the searches
+// from the start and from the end.
+func (d *differ) middleSnake(aStart, aEnd, bStart, bEnd int) (xStart, yStart, xEnd, yEnd int) {
+	n, m := aEnd-aStart, bEnd-bStart
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+	for steps := 0; steps <= max; steps++ {
+		for k := -steps; k <= steps; k += 2 {
+			x := forward[offset+k+1]
+			if k != -steps && (k == steps || forward[offset+k-1] >= forward[offset+k+1]) {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aStart+x] == d.b[bStart+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if c := delta - k; odd && c >= -(steps-1) && c <= steps-1 && x+backward[offset+c] >= n {
+				return aStart + x0, bStart + y0, aStart + x, bStart + y
+			}
+		}
+		// the backward search runs on the reversed lines
+		for c := -steps; c <= steps; c += 2 {
+			x := backward[offset+c+1]
+			if c != -steps && (c == steps || backward[offset+c-1] >= backward[offset+c+1]) {
+				x = backward[offset+c-1] + 1
+			}
+			y := x - c
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aEnd-1-x] == d.b[bEnd-1-y] {
+				x++
+				y++
+			}
+			backward[offset+c] = x
+			if k := delta - c; !odd && k >= -steps && k <= steps && x+forward[offset+k] >= n {
+				return aEnd - x, bEnd - y, aEnd - x0, bEnd - y0
+			}
+		}
+	}
+	panic("no middle snake found")
+}
+
+// Split the content into lines. If the last line has no line ending, a
+// newline is appended to it, so that it differs from the same line with a
+// line ending; unifiedDiff reports it as "\ No newline at end of file".
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if !strings.HasSuffix(s, "\n") {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// Return the differences between the old and the new content of the file in
+// the unified diff format.
+func unifiedDiff(filename, oldContent, newContent string) string {
+	lines := diffLines(splitLines(oldContent), splitLines(newContent))
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", filename, filename)
+	for start := 0; start < len(lines); {
+		// find the next change
+		for start < len(lines) && lines[start].kind == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		// extend the hunk until there are more than 2*diffContext
+		// unchanged lines in a row
+		end, unchanged := start, 0
+		for i := start; i < len(lines) && unchanged <= 2*diffContext; i++ {
+			if lines[i].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+				end = i + 1
+			}
+		}
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContext
+		if to > len(lines) {
+			to = len(lines)
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n",
+			hunkRange(lines[from].oldLine, oldCount), hunkRange(lines[from].newLine, newCount))
+		for _, line := range lines[from:to] {
+			if strings.HasSuffix(line.text, "\n") {
+				fmt.Fprintf(buf, "%c%s\\ No newline at end of file\n", line.kind, line.text)
+			} else {
+				fmt.Fprintf(buf, "%c%s\n", line.kind, line.text)
+			}
+		}
+		start = to
+	}
+	return buf.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,117 @@
+// Command inifmt formats ini files.
+//
+// Usage:
+//
+//	inifmt [flags] [FILE...]
+//
+// Without files, inifmt formats the standard input. By default, the formatted
+// files are written to the standard output. The flags are:
+//
+//	-l       list the files whose formatting differs from inifmt's
+//	-d       print a unified diff of the changes instead of the result
+//	-w       write the result back to the files
+//	-align   align the values of each section
+//	-sort    sort the properties of each section by name
+//	-comment change every comment marker to the given character (# or ;)
+//	-blank   number of blank lines between sections (default 1)
+//
+// With -l or -d, inifmt exits with status 1 if any file is not formatted, so
+// that it can be used to check the formatting in continuous integration. It
+// exits with status 2 if a file cannot be read or parsed.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/derdon/ini"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("inifmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	write := flags.Bool("w", false, "write the result to the files")
+	options := ini.DefaultFormatOptions
+	flags.BoolVar(&options.AlignValues, "align", false, "align the values of each section")
+	flags.BoolVar(&options.SortKeys, "sort", false, "sort the properties of each section")
+	marker := flags.String("comment", "", "comment marker, # or ;")
+	flags.IntVar(&options.BlankLines, "blank", options.BlankLines, "blank lines between sections")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	switch *marker {
+	case "":
+	case "#", ";":
+		options.CommentMarker = (*marker)[0]
+	default:
+		fmt.Fprintf(stderr, "inifmt: invalid comment marker %q\n", *marker)
+		return 2
+	}
+	if options.BlankLines < 0 {
+		fmt.Fprintln(stderr, "inifmt: -blank must not be negative")
+		return 2
+	}
+	filenames := flags.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	for _, filename := range filenames {
+		if *write && filename == "-" {
+			fmt.Fprintln(stderr, "inifmt: cannot use -w with the standard input")
+			return 2
+		}
+	}
+	status := 0
+	for _, filename := range filenames {
+		original, err := readFile(filename, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "inifmt: %v\n", err)
+			status = 2
+			continue
+		}
+		formatted, err := ini.Format(original, &options)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", filename, err)
+			status = 2
+			continue
+		}
+		changed := formatted != original
+		if *list && changed {
+			fmt.Fprintln(stdout, filename)
+		}
+		if *diff && changed {
+			fmt.Fprint(stdout, unifiedDiff(filename, original, formatted))
+		}
+		if (*list || *diff) && changed && status == 0 {
+			status = 1
+		}
+		if *write && changed {
+			if err := os.WriteFile(filename, []byte(formatted), 0); err != nil {
+				fmt.Fprintf(stderr, "inifmt: %v\n", err)
+				status = 2
+			}
+		}
+		if !*list && !*diff && !*write {
+			fmt.Fprint(stdout, formatted)
+		}
+	}
+	return status
+}
+
+func readFile(filename string, stdin io.Reader) (string, error) {
+	if filename == "-" {
+		content, err := io.ReadAll(bufio.NewReader(stdin))
+		return string(content), err
+	}
+	content, err := os.ReadFile(filename)
+	return string(content), err
+}
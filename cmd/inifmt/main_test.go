@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const unformatted = "[a]\nx=1\n\n\n[b]\ny  =  2\n"
+const formatted = "[a]\nx = 1\n\n[b]\ny = 2\n"
+
+func runInifmt(stdin string, args ...string) (status int, stdout, stderr string) {
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	status = run(args, strings.NewReader(stdin), outBuf, errBuf)
+	return status, outBuf.String(), errBuf.String()
+}
+
+func TestFormatStdin(t *testing.T) {
+	status, stdout, stderr := runInifmt(unformatted)
+	if status != 0 || stdout != formatted {
+		t.Errorf("expected status 0 and %q, got %d and %q (%s)", formatted, status, stdout, stderr)
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	status, stdout, stderr := runInifmt("[a]\nzz=1\n# c\nb=2\n", "-sort", "-align", "-comment", ";")
+	expected := "[a]\n; c\nb  = 2\nzz = 1\n"
+	if status != 0 || stdout != expected {
+		t.Errorf("expected status 0 and %q, got %d and %q (%s)", expected, status, stdout, stderr)
+	}
+	status, _, _ = runInifmt("", "-comment", "//")
+	if status != 2 {
+		t.Errorf("expected status 2 for an invalid comment marker, got %d", status)
+	}
+}
+
+func TestListAndWrite(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.ini")
+	bad := filepath.Join(dir, "bad.ini")
+	os.WriteFile(good, []byte(formatted), 0644)
+	os.WriteFile(bad, []byte(unformatted), 0644)
+	status, stdout, _ := runInifmt("", "-l", good, bad)
+	if status != 1 || stdout != bad+"\n" {
+		t.Errorf("expected status 1 and %q, got %d and %q", bad+"\n", status, stdout)
+	}
+	status, stdout, _ = runInifmt("", "-w", good, bad)
+	if status != 0 || stdout != "" {
+		t.Errorf("expected status 0 and no output, got %d and %q", status, stdout)
+	}
+	content, _ := os.ReadFile(bad)
+	if string(content) != formatted {
+		t.Errorf("expected %q, got %q", formatted, content)
+	}
+	status, _, _ = runInifmt("", "-l", good, bad)
+	if status != 0 {
+		t.Errorf("expected status 0 after formatting, got %d", status)
+	}
+}
+
+func TestWriteStdin(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	os.WriteFile("a.ini", []byte(unformatted), 0644)
+	for _, args := range [][]string{{"-w"}, {"-w", "-"}, {"-w", "a.ini", "-"}} {
+		status, _, stderr := runInifmt(unformatted, args...)
+		if status != 2 || !strings.Contains(stderr, "cannot use -w with the standard input") {
+			t.Errorf("%v: expected status 2 and an error, got %d and %q", args, status, stderr)
+		}
+	}
+	if _, err := os.Stat("-"); !os.IsNotExist(err) {
+		t.Errorf("expected no file named -, got %v", err)
+	}
+	if content, _ := os.ReadFile("a.ini"); string(content) != unformatted {
+		t.Errorf("expected the file to be unchanged, got %q", content)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	status, stdout, _ := runInifmt(unformatted, "-d")
+	expected := `--- -
++++ -
+@@ -1,6 +1,5 @@
+ [a]
+-x=1
+-
++x = 1
+ 
+ [b]
+-y  =  2
++y = 2
+`
+	if status != 1 || stdout != expected {
+		t.Errorf("expected status 1 and\n%s\ngot %d and\n%s", expected, status, stdout)
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	new := "1\nchanged\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\nadded\n"
+	expected := `--- f
++++ f
+@@ -1,5 +1,5 @@
+ 1
+-2
++changed
+ 3
+ 4
+ 5
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++added
+`
+	if diff := unifiedDiff("f", old, new); diff != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, diff)
+	}
+}
+
+func TestDiffNoFinalNewline(t *testing.T) {
+	expected := `--- f
++++ f
+@@ -1,2 +1,2 @@
+ [a]
+-x = 1
+\ No newline at end of file
++x = 1
+`
+	if diff := unifiedDiff("f", "[a]\nx = 1", "[a]\nx = 1\n"); diff != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, diff)
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20000; i++ {
+		a = append(a, strconv.Itoa(i))
+		if i%1000 != 0 {
+			b = append(b, strconv.Itoa(i))
+		}
+	}
+	b = append(b, "added")
+	removed, added := 0, 0
+	for _, line := range diffLines(a, b) {
+		switch line.kind {
+		case '-':
+			removed++
+		case '+':
+			added++
+		}
+	}
+	if removed != 20 || added != 1 {
+		t.Errorf("expected 20 removed and 1 added line, got %d and %d", removed, added)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	status, _, stderr := runInifmt("[a]\nbroken\n")
+	if status != 2 || stderr != "-: line 2: missing equal sign\n" {
+		t.Errorf("expected status 2 and a syntax error, got %d and %q", status, stderr)
+	}
+}
@@ -0,0 +1,82 @@
+// Command inilint reports suspicious constructs in ini files.
+//
+// Usage:
+//
+//	inilint [-disable RULE,...] [FILE...]
+//	inilint -rules
+//
+// Without files, inilint checks the standard input. Each warning is printed as
+//
+//	FILE:LINE:COLUMN: MESSAGE (RULE)
+//
+// -rules lists all rules. The exit status is 0 if there are no warnings, 1 if
+// there are warnings and 2 if a file cannot be read or parsed. See the
+// documentation of the package github.com/derdon/ini/lint for how to disable
+// rules within a file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/derdon/ini/lint"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("inilint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	disable := flags.String("disable", "", "comma separated list of rules which are not checked")
+	listRules := flags.Bool("rules", false, "list all rules and exit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *listRules {
+		for _, rule := range lint.Rules {
+			fmt.Fprintf(stdout, "%-22s %s\n", rule.ID, rule.Description)
+		}
+		return 0
+	}
+	options := &lint.Options{}
+	if *disable != "" {
+		options.Disabled = strings.Split(*disable, ",")
+	}
+	filenames := flags.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	status := 0
+	for _, filename := range filenames {
+		var content []byte
+		var err error
+		if filename == "-" {
+			content, err = io.ReadAll(stdin)
+		} else {
+			content, err = os.ReadFile(filename)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "inilint: %v\n", err)
+			status = 2
+			continue
+		}
+		warnings, err := lint.Lint(string(content), options)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", filename, err)
+			status = 2
+			continue
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(stdout, "%s:%s\n", filename, warning)
+		}
+		if len(warnings) > 0 && status == 0 {
+			status = 1
+		}
+	}
+	return status
+}
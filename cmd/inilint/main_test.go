@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func runInilint(stdin string, args ...string) (status int, stdout, stderr string) {
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	status = run(args, strings.NewReader(stdin), outBuf, errBuf)
+	return status, outBuf.String(), errBuf.String()
+}
+
+func TestWarnings(t *testing.T) {
+	status, stdout, _ := runInilint("[a]\nx = 1\nx = 2\n")
+	expected := "-:3:1: property \"x\" was already assigned in line 2 (duplicate-key)\n"
+	if status != 1 || stdout != expected {
+		t.Errorf("expected status 1 and %q, got %d and %q", expected, status, stdout)
+	}
+	status, stdout, _ = runInilint("[a]\nx = 1\nx = 2\n", "-disable", "duplicate-key")
+	if status != 0 || stdout != "" {
+		t.Errorf("expected status 0 and no output, got %d and %q", status, stdout)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	status, _, stderr := runInilint("broken\n")
+	if status != 2 || stderr != "-: line 1: missing equal sign\n" {
+		t.Errorf("expected status 2 and a syntax error, got %d and %q", status, stderr)
+	}
+}
+
+func TestListRules(t *testing.T) {
+	status, stdout, _ := runInilint("", "-rules")
+	if status != 0 || !strings.Contains(stdout, "duplicate-key ") {
+		t.Errorf("expected status 0 and a list of rules, got %d and %q", status, stdout)
+	}
+}
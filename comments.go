@@ -0,0 +1,197 @@
+package ini
+
+import "strings"
+
+// return the marker used for new comments
+func (d *Document) commentMarker() string {
+	if d.CommentMarker == 0 {
+		return "#"
+	}
+	return string(d.CommentMarker)
+}
+
+// Return the marker the comment begins with: the first of the inline comment
+// prefixes it begins with, or else its first character, i.e. # or ;.
+func commentPrefix(comment string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(comment, prefix) {
+			return prefix
+		}
+	}
+	return comment[:1]
+}
+
+// Return the text of the comment lines with their markers and the following
+// space removed. Blank lines are skipped.
+func commentText(lines []string, prefixes []string) []string {
+	text := []string{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		text = append(text, inlineCommentText(line, prefixes))
+	}
+	return text
+}
+
+// Return the given text as comment lines. Lines of the text may contain line
+// breaks.
+func (d *Document) commentLines(text []string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.Join(text, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			lines = append(lines, d.storedLine(d.commentMarker()))
+		} else {
+			lines = append(lines, d.storedLine(d.commentMarker()+" "+line))
+		}
+	}
+	return lines
+}
+
+// Replace the comment lines, but keep the blank lines before them.
+func (d *Document) replaceComments(comments []string, text []string) []string {
+	kept := []string{}
+	for _, line := range comments {
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+		kept = append(kept, line)
+	}
+	if len(text) == 0 {
+		return kept
+	}
+	return append(kept, d.commentLines(text)...)
+}
+
+// Return the lines of the comment at the beginning of the file, without their
+// comment markers.
+func (d *Document) HeaderComment() []string {
+	return commentText(d.Header, d.parseOptions().InlineCommentPrefixes)
+}
+
+// Replace the comment at the beginning of the file by the given lines. The
+// comment is separated from the first section by a blank line. Passing no
+// lines removes the comment.
+func (d *Document) SetHeaderComment(lines ...string) {
+	d.Header = nil
+	if len(lines) > 0 {
+		d.Header = append(d.commentLines(lines), d.storedLine(""))
+	}
+}
+
+// return the comments preceding the given section or property
+func (d *Document) leadingComments(section, property string) (*[]string, error) {
+	if property == "" {
+		sections := d.sections(section)
+		if len(sections) == 0 {
+			return nil, NoSectionError
+		}
+		return &sections[0].Comments, nil
+	}
+	p, err := d.lastProperty(section, property)
+	if err != nil {
+		return nil, err
+	}
+	return &p.Comments, nil
+}
+
+// Return the lines of the comment preceding the given property, without their
+// comment markers. If the property is empty, the comment preceding the first
+// declaration of the section is returned. If the section does not exist,
+// NoSectionError is returned. If the property does not exist,
+// NoPropertyError is returned.
+func (d *Document) Comment(section, property string) ([]string, error) {
+	comments, err := d.leadingComments(section, property)
+	if err != nil {
+		return []string{}, err
+	}
+	return commentText(*comments, d.parseOptions().InlineCommentPrefixes), nil
+}
+
+// Replace the comment preceding the given property, or the first declaration
+// of the section if the property is empty, by the given lines. Blank lines
+// before the comment are kept. Passing no lines removes the comment. The
+// errors are the same as for Comment.
+func (d *Document) SetComment(section, property string, lines ...string) error {
+	comments, err := d.leadingComments(section, property)
+	if err != nil {
+		return err
+	}
+	*comments = d.replaceComments(*comments, lines)
+	return nil
+}
+
+// Return the comment after the given property without its marker. If the
+// property is empty, the comment after the header of the first declaration of
+// the section is returned. The errors are the same as for Comment.
+func (d *Document) InlineComment(section, property string) (string, error) {
+	if property == "" {
+		sections := d.sections(section)
+		if len(sections) == 0 {
+			return "", NoSectionError
+		}
+		return inlineCommentText(sections[0].InlineComment, d.parseOptions().InlineCommentPrefixes), nil
+	}
+	p, err := d.lastProperty(section, property)
+	if err != nil {
+		return "", err
+	}
+	return inlineCommentText(p.InlineComment, d.parseOptions().InlineCommentPrefixes), nil
+}
+
+// Set the comment after the given property, or after the header of the first
+// declaration of the section if the property is empty. An empty comment
+// removes it. The errors are the same as for Comment.
+//
+// The parser only recognizes inline comments if their marker is one of
+// ParseOptions.InlineCommentPrefixes. Otherwise, the comment of a property
+// would become part of its value, and a section header followed by a comment
+// would be a syntax error. So unless the comment marker of the document is
+// one of the prefixes of its options, UnsupportedInlineCommentError is
+// returned.
+func (d *Document) SetInlineComment(section, property, comment string) error {
+	if comment != "" {
+		if !isInlineCommentPrefix(d.commentMarker(), d.parseOptions().InlineCommentPrefixes) {
+			return UnsupportedInlineCommentError{d.commentMarker()}
+		}
+		comment = d.commentMarker() + " " + comment
+	}
+	if property == "" {
+		sections := d.sections(section)
+		if len(sections) == 0 {
+			return NoSectionError
+		}
+		sections[0].InlineComment = comment
+		sections[0].raw = ""
+		return nil
+	}
+	p, err := d.lastProperty(section, property)
+	if err != nil {
+		return err
+	}
+	p.InlineComment = comment
+	p.raw = ""
+	return nil
+}
+
+// returns true if the parser recognizes inline comments starting with the
+// given marker
+func isInlineCommentPrefix(marker string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the text of the comment without its marker, see commentPrefix, and
+// the following space.
+func inlineCommentText(comment string, prefixes []string) string {
+	if comment == "" {
+		return ""
+	}
+	return strings.TrimPrefix(comment[len(commentPrefix(comment, prefixes)):], " ")
+}
@@ -0,0 +1,182 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeaderComment(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	expectValues([]string{"header comment"}, doc.HeaderComment(), t)
+	doc.SetHeaderComment("managed by deploy tool", "do not edit")
+	expectValues([]string{"managed by deploy tool", "do not edit"}, doc.HeaderComment(), t)
+	reparsed, err := NewDocumentFromString(doc.String())
+	assertErrorIsNil(err, t)
+	expectValues(doc.HeaderComment(), reparsed.HeaderComment(), t)
+	comment, _ := reparsed.Comment("server", "")
+	expectValues([]string{}, comment, t)
+	doc.SetHeaderComment()
+	if len(doc.Header) != 0 {
+		t.Errorf("expected the header to be removed, got %q", doc.Header)
+	}
+}
+
+func TestComment(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	comment, err := doc.Comment("server", "host")
+	assertErrorIsNil(err, t)
+	expectValues([]string{"the host to bind to"}, comment, t)
+	comment, err = doc.Comment("database", "")
+	assertErrorIsNil(err, t)
+	expectValues([]string{"database settings"}, comment, t)
+	doc.CommentMarker = ';'
+	assertErrorIsNil(doc.SetComment("database", "", "managed by deploy tool\n", "see docs"), t)
+	assertErrorIsNil(doc.SetComment("server", "host"), t)
+	assertErrorIsNil(doc.SetComment("database", "user", "the admin"), t)
+	expected := `# header comment
+
+[server]
+host = localhost
+port=80
+
+; managed by deploy tool
+;
+; see docs
+[database]
+; the admin
+user = root
+[server]
+port = 8080
+# trailing comment
+`
+	expectDocumentString(expected, doc, t)
+	if _, err := doc.Comment("missing", ""); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	if err := doc.SetComment("server", "missing", "x"); err != (NoPropertyError{"missing"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+}
+
+func TestInlineComment(t *testing.T) {
+	options := &ParseOptions{InlineCommentPrefixes: []string{"#", ";"}}
+	doc, _ := ParseDocument(strings.NewReader(exampleDocument), options)
+	assertErrorIsNil(doc.SetInlineComment("server", "port", "managed by deploy tool"), t)
+	assertErrorIsNil(doc.SetInlineComment("database", "", "primary"), t)
+	comment, err := doc.InlineComment("server", "port")
+	assertErrorIsNil(err, t)
+	expectValue("managed by deploy tool", comment, t)
+	comment, err = doc.InlineComment("server", "")
+	assertErrorIsNil(err, t)
+	expectValue("", comment, t)
+	expected := `# header comment
+
+[server]
+; the host to bind to
+host = localhost
+port=80
+
+# database settings
+[database] # primary
+user = root
+[server]
+port = 8080 # managed by deploy tool
+# trailing comment
+`
+	expectDocumentString(expected, doc, t)
+	doc.Format(&FormatOptions{CommentMarker: ';'})
+	comment, _ = doc.InlineComment("database", "")
+	expectValue("primary", comment, t)
+	if s := doc.Sections[1].String(); s != "[database] ; primary" {
+		t.Errorf("expected the marker to be changed, got %q", s)
+	}
+	assertErrorIsNil(doc.SetInlineComment("server", "port", ""), t)
+	if s := doc.Sections[2].Properties[0].String(); s != "port = 8080" {
+		t.Errorf("expected the comment to be removed, got %q", s)
+	}
+	if err := doc.SetInlineComment("missing", "", "x"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	doc, _ = NewDocumentFromString(exampleDocument)
+	if err := doc.SetInlineComment("server", "port", "x"); err != (UnsupportedInlineCommentError{"#"}) {
+		t.Errorf("expected UnsupportedInlineCommentError, got %v", err)
+	}
+	doc, _ = ParseDocument(strings.NewReader(exampleDocument), &ParseOptions{InlineCommentPrefixes: []string{";"}})
+	if err := doc.SetInlineComment("server", "port", "x"); err != (UnsupportedInlineCommentError{"#"}) {
+		t.Errorf("expected UnsupportedInlineCommentError, got %v", err)
+	}
+	doc.CommentMarker = ';'
+	assertErrorIsNil(doc.SetInlineComment("server", "port", "x"), t)
+	expectDocumentString(strings.Replace(exampleDocument, "8080\n", "8080 ; x\n", 1), doc, t)
+}
+
+func TestParseInlineComments(t *testing.T) {
+	input := "[server]   # the server\nhost = localhost  ; bind address\nport = 80\n"
+	options := &ParseOptions{InlineCommentPrefixes: []string{"#", ";"}}
+	doc, err := ParseDocument(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	expectDocumentString(input, doc, t)
+	comment, _ := doc.InlineComment("server", "")
+	expectValue("the server", comment, t)
+	comment, _ = doc.InlineComment("server", "host")
+	expectValue("bind address", comment, t)
+	value, _ := doc.Get("server", "host")
+	expectValue("localhost", value, t)
+	assertErrorIsNil(doc.SetInlineComment("server", "port", "default"), t)
+	assertErrorIsNil(doc.Set("server", "host", "0.0.0.0"), t)
+	reparsed, err := ParseDocument(strings.NewReader(doc.String()), options)
+	assertErrorIsNil(err, t)
+	comment, _ = reparsed.InlineComment("server", "port")
+	expectValue("default", comment, t)
+	comment, _ = reparsed.InlineComment("server", "host")
+	expectValue("bind address", comment, t)
+	value, _ = reparsed.Get("server", "host")
+	expectValue("0.0.0.0", value, t)
+}
+
+func TestMultiByteInlineCommentPrefix(t *testing.T) {
+	input := "// the server\n[server] // main\nhost = localhost // bind address\n"
+	options := &ParseOptions{InlineCommentPrefixes: []string{"//"}}
+	doc, err := ParseDocument(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	comment, _ := doc.InlineComment("server", "")
+	expectValue("main", comment, t)
+	comment, _ = doc.InlineComment("server", "host")
+	expectValue("bind address", comment, t)
+	lines, _ := doc.Comment("server", "")
+	expectValues([]string{"the server"}, lines, t)
+	doc.Format(&FormatOptions{CommentMarker: '#'})
+	expectDocumentString("# the server\n[server] // main\nhost = localhost // bind address\n", doc, t)
+	var comments []string
+	err = Walk(strings.NewReader(input), options, func(token *Token) error {
+		comments = append(comments, token.Comment)
+		return nil
+	})
+	assertErrorIsNil(err, t)
+	expectValues([]string{"the server", "main", "bind address"}, comments, t)
+}
+
+func TestEscapeInlineCommentPrefixes(t *testing.T) {
+	options := &ParseOptions{InlineCommentPrefixes: []string{"//", "#"}}
+	doc, err := ParseDocument(strings.NewReader("[s]\nk = v\n"), options)
+	assertErrorIsNil(err, t)
+	assertErrorIsNil(doc.Set("s", "k", "x //y"), t)
+	assertErrorIsNil(doc.Set("s", "size", `5" screen`), t)
+	assertErrorIsNil(doc.SetInlineComment("s", "size", "note"), t)
+	assertErrorIsNil(doc.AddSection("a //b"), t)
+	reparsed, err := ParseDocument(strings.NewReader(doc.String()), options)
+	assertErrorIsNil(err, t)
+	value, _ := reparsed.Get("s", "k")
+	expectValue("x //y", value, t)
+	value, _ = reparsed.Get("s", "size")
+	expectValue(`5" screen`, value, t)
+	comment, _ := reparsed.InlineComment("s", "size")
+	expectValue("note", comment, t)
+	if !reparsed.HasSection("a //b") {
+		t.Errorf("expected the section to be read back, got %q", doc.String())
+	}
+	// without inline comments, neither is escaped
+	doc, _ = NewDocumentFromString("[s]\n")
+	assertErrorIsNil(doc.Set("s", "k", `x //y "z"`), t)
+	expectDocumentString("[s]\nk = x //y \"z\"\n", doc, t)
+}
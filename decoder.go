@@ -0,0 +1,192 @@
+package ini
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// A TokenKind tells which element of a file a Token stands for.
+type TokenKind int
+
+const (
+	// a section header like [name]
+	SectionToken TokenKind = iota
+	// an assignment like property = value
+	PropertyToken
+	// a line which consists of a comment only
+	CommentToken
+)
+
+// A Token is an element of a file as it is returned by Decoder.Next. Names
+// and values are unescaped, but neither case folding nor the duplicate
+// policies of the options are applied.
+type Token struct {
+	Kind TokenKind
+	// the name of the section, or the section a property belongs to
+	Section string
+	// the name of the property, empty for sections and comments
+	Property string
+	Value    string
+	// the text of a comment line, or the inline comment of a section or
+	// property, without its marker, see ParseOptions.InlineCommentPrefixes
+	Comment string
+	// the parents of a section, see ParseOptions.Inheritance
+	Parents []string
+	// written as [[name]] or key[] = value
+	ArraySyntax bool
+	Operator    AssignmentOperator
+	// written without a value, see ParseOptions.AllowNoValue
+	NoValue bool
+	// the position of the first character of the element, both starting
+	// at 1
+	Line   int
+	Column int
+}
+
+// A Decoder reads a file one element at a time, so that large files can be
+// processed without building a Config or a Document. Blank lines are
+// skipped. Syntax errors are returned as a ParseError.
+type Decoder struct {
+	reader  *lineReader
+	options *ParseOptions
+	section string
+	line    int
+	// whether the rest of the current section is skipped, see SkipSection
+	skipping bool
+	err      error
+}
+
+// Get a new Decoder reading from the given ByteReader. If options is nil,
+// the file is read like by NewConfigFromByteReader. A reader which is also
+// an io.Reader is read through a buffer, so it may have been read beyond the
+// last returned element.
+func NewDecoder(reader io.ByteReader, options *ParseOptions) *Decoder {
+	if options == nil {
+		options = defaultParseOptions
+	}
+	return &Decoder{reader: newLineReader(reader), options: options}
+}
+
+// Return the next element of the file. At the end of the file, io.EOF is
+// returned. After an error, the same error is returned by all further calls.
+func (d *Decoder) Next() (*Token, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	token, err := d.next()
+	if err != nil {
+		if err != io.EOF {
+			err = ParseError{d.line, err}
+		}
+		d.err = err
+	}
+	return token, err
+}
+
+func (d *Decoder) next() (*Token, error) {
+	for {
+		raw, err := d.reader.readSlice()
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 {
+			return nil, io.EOF
+		}
+		d.line++
+		// only section headers end skipping, so other lines need not be
+		// copied
+		if d.skipping && !bytes.HasPrefix(bytes.TrimLeft(raw, " \t"), []byte("[")) {
+			continue
+		}
+		line := strings.TrimRight(string(raw), "\r\n")
+		content, comment := splitInlineComment(line, d.options.InlineCommentPrefixes)
+		trimmedLine := strings.TrimSpace(content)
+		if trimmedLine == "" && comment == "" {
+			continue
+		}
+		token := &Token{
+			Section: d.section,
+			Comment: inlineCommentText(comment, d.options.InlineCommentPrefixes),
+			Line:    d.line,
+			Column:  len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+		if trimmedLine == "" || trimmedLine[0] == '#' || trimmedLine[0] == ';' {
+			token.Kind = CommentToken
+			if trimmedLine != "" {
+				token.Comment = inlineCommentText(strings.TrimSpace(line), nil)
+			}
+			return token, nil
+		}
+		if isSection(trimmedLine) {
+			header, err := parseSectionHeader(trimmedLine, d.options)
+			if err != nil {
+				return nil, err
+			}
+			d.section, d.skipping = header.name, false
+			token.Kind = SectionToken
+			token.Section = header.name
+			token.Parents = header.parents
+			token.ArraySyntax = header.array
+			return token, nil
+		}
+		if d.skipping {
+			continue
+		}
+		if d.section == "" {
+			return nil, AssignmentOutsideSectionError
+		}
+		a, err := parseAssignment(content, d.options)
+		if err != nil {
+			return nil, err
+		}
+		token.Kind = PropertyToken
+		token.Property = a.Property
+		token.Value = a.Value
+		token.ArraySyntax = a.array
+		token.Operator = a.op
+		token.NoValue = a.flag
+		return token, nil
+	}
+}
+
+// Skip the remaining properties and comments of the current section. The
+// next token is the header of the following section. The skipped lines are
+// not parsed, so they may contain syntax errors.
+func (d *Decoder) SkipSection() {
+	d.skipping = true
+}
+
+// SkipSection can be returned by the function passed to Walk to skip the
+// remaining elements of the current section.
+var SkipSection = errors.New("skip the rest of the section")
+
+// SkipAll can be returned by the function passed to Walk to stop reading the
+// file.
+var SkipAll = errors.New("skip the rest of the file")
+
+// Read the file one element at a time and call fn for each of them, see
+// Decoder. If fn returns SkipSection, the rest of the current section is
+// skipped; if it returns SkipAll, Walk stops and returns nil. Any other error
+// stops Walk and is returned, as are syntax errors.
+func Walk(reader io.ByteReader, options *ParseOptions, fn func(token *Token) error) error {
+	d := NewDecoder(reader, options)
+	for {
+		token, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch err := fn(token); err {
+		case nil:
+		case SkipSection:
+			d.SkipSection()
+		case SkipAll:
+			return nil
+		default:
+			return err
+		}
+	}
+}
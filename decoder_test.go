@@ -0,0 +1,104 @@
+package ini
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const decoderInput = `# inventory
+[web]
+  host = a.example.com
+port = 80
+
+[db : web]
+; the primary
+host = \x20db
+[[db]]
+bad line
+`
+
+func TestDecoder(t *testing.T) {
+	options := &ParseOptions{Inheritance: true}
+	d := NewDecoder(strings.NewReader(decoderInput), options)
+	expected := []Token{
+		{Kind: CommentToken, Comment: "inventory", Line: 1, Column: 1},
+		{Kind: SectionToken, Section: "web", Line: 2, Column: 1},
+		{Kind: PropertyToken, Section: "web", Property: "host", Value: "a.example.com", Line: 3, Column: 3},
+		{Kind: PropertyToken, Section: "web", Property: "port", Value: "80", Line: 4, Column: 1},
+		{Kind: SectionToken, Section: "db", Parents: []string{"web"}, Line: 6, Column: 1},
+		{Kind: CommentToken, Section: "db", Comment: "the primary", Line: 7, Column: 1},
+		{Kind: PropertyToken, Section: "db", Property: "host", Value: " db", Line: 8, Column: 1},
+		{Kind: SectionToken, Section: "[db]", Line: 9, Column: 1}}
+	for _, token := range expected {
+		next, err := d.Next()
+		assertErrorIsNil(err, t)
+		if next == nil || !reflect.DeepEqual(*next, token) {
+			t.Errorf("expected %+v, got %+v", token, next)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := d.Next(); err != (ParseError{10, MissingEqualSignError}) {
+			t.Errorf("expected ParseError in line 10, got %v", err)
+		}
+	}
+}
+
+func TestDecoderOptions(t *testing.T) {
+	input := "[a]\nflag\nx[] = 1 # one\n[[b]]\n"
+	options := &ParseOptions{AllowNoValue: true, ArrayKeys: true, SectionArrays: true, InlineCommentPrefixes: []string{"#"}}
+	d := NewDecoder(strings.NewReader(input), options)
+	d.Next()
+	token, _ := d.Next()
+	if !token.NoValue || token.Property != "flag" {
+		t.Errorf("expected the flag, got %+v", token)
+	}
+	token, _ = d.Next()
+	if !token.ArraySyntax || token.Property != "x" || token.Value != "1" || token.Comment != "one" {
+		t.Errorf("expected the array key with a comment, got %+v", token)
+	}
+	token, _ = d.Next()
+	if !token.ArraySyntax || token.Section != "b" {
+		t.Errorf("expected the section array, got %+v", token)
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	d = NewDecoder(strings.NewReader("x = 1\n"), nil)
+	if _, err := d.Next(); err != (ParseError{1, AssignmentOutsideSectionError}) {
+		t.Errorf("expected AssignmentOutsideSectionError, got %v", err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	hosts := []string{}
+	err := Walk(strings.NewReader(decoderInput), nil, func(token *Token) error {
+		switch {
+		case token.Kind == SectionToken && token.Section != "web":
+			return SkipAll
+		case token.Kind == PropertyToken && token.Property == "host":
+			hosts = append(hosts, token.Value)
+		}
+		return nil
+	})
+	assertErrorIsNil(err, t)
+	expectValues([]string{"a.example.com"}, hosts, t)
+	sections := []string{}
+	err = Walk(strings.NewReader(decoderInput), nil, func(token *Token) error {
+		if token.Kind == SectionToken {
+			sections = append(sections, token.Section)
+		}
+		return SkipSection
+	})
+	assertErrorIsNil(err, t)
+	expectValues([]string{"web", "db : web", "db"}, sections, t)
+	stop := errors.New("stop")
+	err = Walk(strings.NewReader(decoderInput), nil, func(token *Token) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("expected the error of the function, got %v", err)
+	}
+}
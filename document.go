@@ -0,0 +1,713 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Document is an ordered representation of an ini file. Unlike a Config, it
+// keeps comments, blank lines, repeated sections and the order of sections
+// and properties, so that a file can be edited without touching the lines
+// which were not changed.
+type Document struct {
+	// comments and blank lines before the first section
+	Header []string
+	// the sections in the order of their appearance. A section which is
+	// declared several times occurs several times.
+	Sections []*DocumentSection
+	// comments and blank lines after the last section
+	Footer []string
+	// the marker of the comments added with SetHeaderComment, SetComment and
+	// SetInlineComment, either '#' or ';'. If it is 0, '#' is used.
+	CommentMarker byte
+	// the line ending of new and changed lines, taken from the first line of
+	// the file. If it is empty, "\n" is used. Lines which were read keep
+	// their own line ending: the lines of Header, Comments and Footer which
+	// were terminated by "\r\n" keep the "\r".
+	LineEnding string
+	// whether the last line of the file has no line ending. String then
+	// omits it after the last line as well.
+	NoFinalNewline bool
+	// the options the document was parsed with, nil for the default
+	// options, see parseOptions
+	options *ParseOptions
+}
+
+// A DocumentSection is a section of a Document together with the comments and
+// blank lines directly preceding its header.
+type DocumentSection struct {
+	Name       string
+	Comments   []string
+	Properties []*DocumentProperty
+	// the sections this section inherits from, see ParseOptions.Inheritance
+	Parents []string
+	// whether the header has the form [[name]], see ParseOptions.SectionArrays
+	ArraySyntax bool
+	// the comment after the header including its marker, e.g. "# note"
+	InlineComment string
+	// the line of the section header, starting at 1. It is 0 for sections
+	// which were not read from a file.
+	Line int
+	// the header line as it was read, or "" if the section was changed
+	raw string
+	// the options of the document, used by Get and Set
+	options *ParseOptions
+}
+
+// A DocumentProperty is an assignment of a Document together with the comments
+// and blank lines directly preceding it.
+type DocumentProperty struct {
+	Name     string
+	Value    string
+	Comments []string
+	// whether the property is written in the form key[] = value, see
+	// ParseOptions.ArrayKeys
+	ArraySyntax bool
+	// how the assignment changes the value of the property, see
+	// ParseOptions.Operators
+	Operator AssignmentOperator
+	// whether the property is written without an equal sign and a value,
+	// see ParseOptions.AllowNoValue. Value is empty then.
+	NoValue bool
+	// the comment after the assignment including its marker, e.g. "# note"
+	InlineComment string
+	// the line of the assignment, starting at 1. It is 0 for properties
+	// which were not read from a file.
+	Line int
+	// the assignment as it was read, or "" if the property was changed
+	raw string
+}
+
+// return the options the document was parsed with, or the default options
+// for a Document which was not created by one of the constructors
+func (d *Document) parseOptions() *ParseOptions {
+	if d.options == nil {
+		return defaultParseOptions
+	}
+	return d.options
+}
+
+// Get a new empty document.
+func NewDocument() *Document {
+	return &Document{options: defaultParseOptions}
+}
+
+// Create a new *Document from a string. This is a shortcut for:
+//
+//	NewDocumentFromByteReader(strings.NewReader(s))
+func NewDocumentFromString(s string) (*Document, error) {
+	return NewDocumentFromByteReader(strings.NewReader(s))
+}
+
+// Create a new *Document from a file. This is a shortcut for:
+//
+//	NewDocumentFromByteReader(bufio.NewReader(file))
+func NewDocumentFromFile(file *os.File) (*Document, error) {
+	return NewDocumentFromByteReader(bufio.NewReader(file))
+}
+
+// Create a new *Document by a filename.
+func NewDocumentFromFilename(filename string) (*Document, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return NewDocument(), err
+	}
+	defer file.Close()
+	return NewDocumentFromFile(file)
+}
+
+// Create a new *Document from a ByteReader. The syntax is the same as for
+// NewConfigFromByteReader, but syntax errors are returned as a ParseError
+// which contains the number of the offending line.
+func NewDocumentFromByteReader(reader io.ByteReader) (*Document, error) {
+	return parseDocumentSource("", reader, nil)
+}
+
+// Create a new *Document from a ByteReader using the given options. If
+// options is nil, the result is the same as with NewDocumentFromByteReader.
+//
+// With the policies DuplicateMerge and DuplicateKeepAll, a document keeps all
+// declarations of repeated sections and properties. With DuplicateFirstWins
+// and DuplicateLastWins, the ignored declarations are removed from the
+// document together with their comments.
+func ParseDocument(reader io.ByteReader, options *ParseOptions) (*Document, error) {
+	return parseDocumentSource("", reader, options)
+}
+
+func parseDocument(reader *lineReader, options *ParseOptions) (*Document, error) {
+	doc := NewDocument()
+	doc.options = options
+	duplicates := newDuplicateTracker(options)
+	// comments and blank lines which were not assigned to an element yet
+	var pending []string
+	var section *DocumentSection
+	// whether the properties of the current section are ignored because
+	// the section was declared before
+	skipSection := false
+	// whether the current section is a record of its own, see Records
+	record := false
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return doc, err
+		}
+		if line == "" {
+			break
+		}
+		if lineNumber == 1 && strings.HasSuffix(line, "\r\n") {
+			doc.LineEnding = "\r\n"
+		}
+		doc.NoFinalNewline = !strings.HasSuffix(line, "\n")
+		// the line as it is kept, with the \r of its line ending, see
+		// String
+		raw := strings.TrimSuffix(line, "\n")
+		if doc.NoFinalNewline {
+			raw = doc.storedLine(raw)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		content, comment := splitInlineComment(line, options.InlineCommentPrefixes)
+		trimmedLine := strings.TrimSpace(content)
+		if trimmedLine == "" || trimmedLine[0] == '#' || trimmedLine[0] == ';' {
+			pending = append(pending, raw)
+			continue
+		}
+		if isSection(trimmedLine) {
+			if section == nil {
+				// comments before the first section belong to the
+				// header of the file if they are separated from the
+				// section by a blank line
+				doc.Header, pending = splitAtLastBlankLine(pending)
+			}
+			header, err := parseSectionHeader(trimmedLine, options)
+			if err != nil {
+				return doc, ParseError{lineNumber, err}
+			}
+			section = &DocumentSection{
+				Name:          header.name,
+				Parents:       header.parents,
+				ArraySyntax:   header.array,
+				InlineComment: comment,
+				Comments:      pending,
+				Line:          lineNumber,
+				raw:           raw,
+				options:       options}
+			pending = nil
+			// blocks of the form [[name]] are meant to be repeated
+			repeated := false
+			if header.array {
+				duplicates.record(section.Name, lineNumber)
+			} else if repeated, err = duplicates.section(section.Name, lineNumber); err != nil {
+				return doc, err
+			}
+			if repeated && options.DuplicateSections == DuplicateLastWins {
+				doc.RemoveSection(section.Name)
+			}
+			skipSection = repeated && options.DuplicateSections == DuplicateFirstWins
+			record = header.array || options.DuplicateSections == DuplicateKeepAll
+			if !skipSection {
+				doc.Sections = append(doc.Sections, section)
+			}
+			continue
+		}
+		a, err := parseAssignment(content, options)
+		if err != nil {
+			return doc, ParseError{lineNumber, err}
+		}
+		if section == nil {
+			return doc, ParseError{lineNumber, AssignmentOutsideSectionError}
+		}
+		if skipSection {
+			pending = nil
+			continue
+		}
+		// properties of the form key[] = value and operators other
+		// than = are meant to be used several times
+		repeated := false
+		if !a.array && a.op == OperatorAssign {
+			repeated, err = duplicates.property(section.Name, a.Property, lineNumber)
+			if err != nil {
+				return doc, err
+			}
+		}
+		if repeated {
+			switch options.DuplicateProperties {
+			case DuplicateFirstWins:
+				pending = nil
+				continue
+			case DuplicateLastWins:
+				// the properties of a record are tracked on their
+				// own, see duplicateTracker
+				if record {
+					section.removeProperty(a.Property, options.CaseFolding)
+				} else {
+					doc.RemoveProperty(section.Name, a.Property)
+				}
+			}
+		}
+		section.Properties = append(section.Properties, &DocumentProperty{
+			Name:          a.Property,
+			Value:         a.Value,
+			Comments:      pending,
+			ArraySyntax:   a.array,
+			Operator:      a.op,
+			NoValue:       a.flag,
+			InlineComment: comment,
+			Line:          lineNumber,
+			raw:           raw})
+		pending = nil
+	}
+	if section == nil {
+		doc.Header = pending
+	} else {
+		doc.Footer = pending
+	}
+	return doc, nil
+}
+
+// split the lines after the last blank line
+func splitAtLastBlankLine(lines []string) (before, after []string) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			return lines[:i+1], lines[i+1:]
+		}
+	}
+	return nil, lines
+}
+
+// Return the line with the "\r" of the LineEnding of the document, so that
+// String terminates it with the LineEnding like a new line.
+func (d *Document) storedLine(line string) string {
+	if d.LineEnding == "\r\n" {
+		return line + "\r"
+	}
+	return line
+}
+
+// Return the document as it would be written to a file. Lines which were not
+// changed are returned exactly as they were read, including their line
+// ending; changed and new sections and properties are written like in
+// Config.String and terminated by the LineEnding of the document. The last
+// line is only terminated unless NoFinalNewline is set.
+func (d *Document) String() string {
+	lineEnding := d.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+	prefixes := d.parseOptions().InlineCommentPrefixes
+	buf := new(bytes.Buffer)
+	// the kept lines end with the \r of a \r\n line ending
+	writeLines := func(lines []string) {
+		for _, line := range lines {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	writeLines(d.Header)
+	for _, section := range d.Sections {
+		writeLines(section.Comments)
+		if section.raw != "" {
+			writeLines([]string{section.raw})
+		} else {
+			buf.WriteString(section.format(prefixes))
+			buf.WriteString(lineEnding)
+		}
+		for _, property := range section.Properties {
+			writeLines(property.Comments)
+			if property.raw != "" {
+				writeLines([]string{property.raw})
+			} else {
+				buf.WriteString(property.format(0, prefixes))
+				buf.WriteString(lineEnding)
+			}
+		}
+	}
+	writeLines(d.Footer)
+	if d.NoFinalNewline {
+		return strings.TrimSuffix(strings.TrimSuffix(buf.String(), "\n"), "\r")
+	}
+	return buf.String()
+}
+
+// Return the header line of the section.
+func (s *DocumentSection) String() string {
+	if s.raw != "" {
+		return strings.TrimSuffix(s.raw, "\r")
+	}
+	return s.format(s.parseOptions().InlineCommentPrefixes)
+}
+
+// Return the header line in its normalized form, escaped for the given inline
+// comment prefixes.
+func (s *DocumentSection) format(prefixes []string) string {
+	name := escapeSectionName(s.Name, prefixes)
+	if len(s.Parents) > 0 {
+		parents := make([]string, len(s.Parents))
+		for i, parent := range s.Parents {
+			parents[i] = escapeSectionName(parent, prefixes)
+		}
+		name += " : " + strings.Join(parents, ", ")
+	}
+	if s.ArraySyntax {
+		name = "[" + name + "]"
+	}
+	return withInlineComment("["+name+"]", s.InlineComment)
+}
+
+// append the inline comment to the line
+func withInlineComment(line, comment string) string {
+	if comment == "" {
+		return line
+	}
+	return line + " " + comment
+}
+
+// Return the assignment line of the property. A changed property is escaped
+// for a file without inline comments; Document.String escapes it for the
+// options of the document.
+func (p *DocumentProperty) String() string {
+	if p.raw != "" {
+		return strings.TrimSuffix(p.raw, "\r")
+	}
+	return p.format(0, nil)
+}
+
+// Return the assignment in its normalized form with the name of the property
+// padded to the given width, escaped for the given inline comment prefixes.
+func (p *DocumentProperty) format(width int, prefixes []string) string {
+	key := p.key(prefixes)
+	if n := utf8.RuneCountInString(key); n < width {
+		key += strings.Repeat(" ", width-n)
+	}
+	var line string
+	switch {
+	case p.Operator == OperatorAppend:
+		line = key + " += " + escape(p.Value, prefixes)
+	case p.Operator == OperatorUnset:
+		line = unsetKeyword + " " + p.key(prefixes)
+	case p.NoValue:
+		line = p.key(prefixes)
+	default:
+		line = key + " = " + escape(p.Value, prefixes)
+	}
+	return withInlineComment(line, p.InlineComment)
+}
+
+// return the name of the property as it is written in the file
+func (p *DocumentProperty) key(prefixes []string) string {
+	if p.ArraySyntax {
+		return escapeProperty(p.Name, prefixes) + "[]"
+	}
+	return escapeProperty(p.Name, prefixes)
+}
+
+// Rewrite all section headers and assignments in the form used by
+// Config.String, i.e. without any surrounding whitespace and with exactly one
+// space on each side of the equal sign. Comments and blank lines are kept.
+func (d *Document) Normalize() {
+	for _, section := range d.Sections {
+		section.raw = ""
+		for _, property := range section.Properties {
+			property.raw = ""
+		}
+	}
+}
+
+// Convert the document to a *Config. Repeated sections are merged and if a
+// property is assigned several times within a section, the last value wins,
+// just like when parsing the file with ParseConfig and the options of the
+// document. Unlike ParseConfig, the inherited properties are not copied into
+// the sections, so that Lookup with Document.Inheritance can still tell
+// where a value comes from; use Flatten to get the Config of ParseConfig.
+func (d *Document) Config() *Config {
+	conf := NewConfig()
+	names := d.spellings()
+	for _, section := range d.Sections {
+		name := names.section(section.Name)
+		conf.AddSection(name)
+		for _, property := range section.Properties {
+			item := &Item{names.property(name, property.Name), property.Value}
+			conf.applyOperator(name, item, property.Operator, d.parseOptions())
+		}
+	}
+	return conf
+}
+
+// Return the first spellings of the section names under the case folding of
+// the document. Like ParseConfig, the names of parent sections count as
+// spellings as well.
+func (d *Document) spellings() *spellings {
+	names := newSpellings(d.parseOptions().CaseFolding)
+	for _, section := range d.Sections {
+		names.section(section.Name)
+		for _, parent := range section.Parents {
+			names.section(parent)
+		}
+	}
+	return names
+}
+
+// Returns true if the document contains a section with the given name,
+// otherwise false.
+func (d *Document) HasSection(section string) bool {
+	return len(d.sections(section)) > 0
+}
+
+// Returns true if a) the given section exists and b) the given property can be
+// found within the section. Otherwise false is returned.
+func (d *Document) HasProperty(section, property string) bool {
+	_, err := d.Get(section, property)
+	return err == nil
+}
+
+// Returns the names of all sections in the order of their first appearance.
+func (d *Document) GetSections() (sections []string) {
+	sections = []string{}
+	seen := make(map[string]bool)
+	for _, section := range d.Sections {
+		key := d.parseOptions().CaseFolding.key(section.Name)
+		if !seen[key] {
+			seen[key] = true
+			sections = append(sections, section.Name)
+		}
+	}
+	return sections
+}
+
+// Get a slice of *Item structs from the given section in the order of their
+// appearance. If a property is assigned several times, only its last value
+// is returned at the position of its first appearance. If the section does
+// not exist, NoSectionError is returned.
+func (d *Document) GetItems(section string) (items []*Item, err error) {
+	items = []*Item{}
+	sections := d.sections(section)
+	if len(sections) == 0 {
+		return items, NoSectionError
+	}
+	seen := make(map[string]bool)
+	for _, s := range sections {
+		for _, property := range s.Properties {
+			key := d.parseOptions().CaseFolding.key(property.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if value, err := d.Get(section, property.Name); err == nil {
+				items = append(items, &Item{property.Name, value})
+			}
+		}
+	}
+	return items, nil
+}
+
+// Get the value of the passed property in the given section. If the property
+// is assigned several times, the last value is returned. If the section does
+// not exist, NoSectionError is returned. If the property does not exist in
+// the given section, NoPropertyError is returned.
+func (d *Document) Get(section, property string) (value string, err error) {
+	values, err := d.resolve(section, property)
+	if err != nil {
+		return value, err
+	}
+	return values[len(values)-1], nil
+}
+
+// Set the given property in the given section to the passed value. Attempting
+// to set values in non-existing sections will return NoSectionError. If the
+// property exists, its last assignment is changed; otherwise the property is
+// appended to the last declaration of the section.
+func (d *Document) Set(section, property, value string) error {
+	p, err := d.lastProperty(section, property)
+	if err == NoSectionError {
+		return err
+	}
+	if err != nil {
+		sections := d.sections(section)
+		last := sections[len(sections)-1]
+		last.Properties = append(last.Properties, &DocumentProperty{Name: property, Value: value})
+		return nil
+	}
+	p.assign(value)
+	return nil
+}
+
+// Change the property to a plain assignment of the given value. The original
+// line is only forgotten if the assignment changes.
+func (p *DocumentProperty) assign(value string) {
+	if p.Value != value || p.Operator != OperatorAssign || p.NoValue {
+		p.Value = value
+		p.Operator = OperatorAssign
+		p.NoValue = false
+		p.raw = ""
+	}
+}
+
+// Add a new section to the end of the document. If a section with this name
+// already exists, the error DuplicateSectionError is returned and the section
+// won't be added.
+func (d *Document) AddSection(section string) error {
+	if d.HasSection(section) {
+		return DuplicateSectionError
+	}
+	d.Sections = append(d.Sections, &DocumentSection{Name: section, options: d.parseOptions()})
+	return nil
+}
+
+// Remove all declarations of the given section including their properties
+// and the comments preceding them. If the section does not exist, the error
+// NoSectionError is returned.
+func (d *Document) RemoveSection(section string) error {
+	if !d.HasSection(section) {
+		return NoSectionError
+	}
+	sections := []*DocumentSection{}
+	for _, s := range d.Sections {
+		if !d.parseOptions().CaseFolding.Equal(s.Name, section) {
+			sections = append(sections, s)
+		}
+	}
+	d.Sections = sections
+	return nil
+}
+
+// Remove all assignments of the given property from the passed section
+// together with the comments preceding them. If no such section exists,
+// NoSectionError will be returned. If the section exists but not the
+// property, NoPropertyError will be returned.
+func (d *Document) RemoveProperty(section, property string) error {
+	if _, err := d.lastProperty(section, property); err != nil {
+		return err
+	}
+	for _, s := range d.sections(section) {
+		s.removeProperty(property, d.parseOptions().CaseFolding)
+	}
+	return nil
+}
+
+// remove all assignments of the property from this declaration of the section
+func (s *DocumentSection) removeProperty(property string, folding CaseFolding) {
+	properties := []*DocumentProperty{}
+	for _, p := range s.Properties {
+		if !folding.Equal(p.Name, property) {
+			properties = append(properties, p)
+		}
+	}
+	s.Properties = properties
+}
+
+// Rename all declarations of the given section, keeping their position,
+// comments and properties. If the section does not exist, NoSectionError is
+// returned. If a different section with the new name already exists,
+// DuplicateSectionError is returned and nothing is changed.
+func (d *Document) RenameSection(section, newName string) error {
+	sections := d.sections(section)
+	if len(sections) == 0 {
+		return NoSectionError
+	}
+	if !d.parseOptions().CaseFolding.Equal(section, newName) && d.HasSection(newName) {
+		return DuplicateSectionError
+	}
+	for _, s := range sections {
+		if s.Name != newName {
+			s.Name = newName
+			s.raw = ""
+		}
+	}
+	return nil
+}
+
+// Rename all assignments of the given property in the passed section,
+// keeping their position, comments and values. If the section does not exist,
+// NoSectionError is returned; if the property does not exist,
+// NoPropertyError is returned. If the section already contains a different
+// property with the new name, DuplicatePropertyError is returned and nothing
+// is changed.
+func (d *Document) RenameProperty(section, property, newName string) error {
+	positions, err := d.propertyPositions(section, property)
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		return NoPropertyError{property}
+	}
+	if !d.parseOptions().CaseFolding.Equal(property, newName) {
+		if existing, _ := d.propertyPositions(section, newName); len(existing) > 0 {
+			return DuplicatePropertyError{newName}
+		}
+	}
+	for _, pos := range positions {
+		p := pos.section.Properties[pos.index]
+		if p.Name != newName {
+			p.Name = newName
+			p.raw = ""
+		}
+	}
+	return nil
+}
+
+// Move all assignments of the given property together with their comments
+// to the end of the last declaration of the target section, keeping their
+// order. If one of the sections does not exist, NoSectionError is returned;
+// if the property does not exist, NoPropertyError is returned. If the target
+// section already contains the property, DuplicatePropertyError is returned
+// and nothing is changed.
+func (d *Document) MoveProperty(section, property, target string) error {
+	positions, err := d.propertyPositions(section, property)
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		return NoPropertyError{property}
+	}
+	targets := d.sections(target)
+	if len(targets) == 0 {
+		return NoSectionError
+	}
+	if d.parseOptions().CaseFolding.Equal(section, target) {
+		return nil
+	}
+	if existing, _ := d.propertyPositions(target, property); len(existing) > 0 {
+		return DuplicatePropertyError{property}
+	}
+	last := targets[len(targets)-1]
+	for _, pos := range positions {
+		last.Properties = append(last.Properties, pos.section.Properties[pos.index])
+	}
+	for _, s := range d.sections(section) {
+		s.removeProperty(property, d.parseOptions().CaseFolding)
+	}
+	return nil
+}
+
+// return all declarations of the given section
+func (d *Document) sections(section string) (sections []*DocumentSection) {
+	for _, s := range d.Sections {
+		if d.parseOptions().CaseFolding.Equal(s.Name, section) {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+// return the last assignment of the given property in the given section
+func (d *Document) lastProperty(section, property string) (*DocumentProperty, error) {
+	sections := d.sections(section)
+	if len(sections) == 0 {
+		return nil, NoSectionError
+	}
+	var last *DocumentProperty
+	for _, s := range sections {
+		for _, p := range s.Properties {
+			if d.parseOptions().CaseFolding.Equal(p.Name, property) {
+				last = p
+			}
+		}
+	}
+	if last == nil {
+		return nil, NoPropertyError{property}
+	}
+	return last, nil
+}
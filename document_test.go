@@ -0,0 +1,274 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const exampleDocument = `# header comment
+
+[server]
+; the host to bind to
+host = localhost
+port=80
+
+# database settings
+[database]
+user = root
+[server]
+port = 8080
+# trailing comment
+`
+
+func expectDocumentString(expected string, doc *Document, t *testing.T) {
+	if s := doc.String(); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+}
+
+func TestParseDocumentRoundTrip(t *testing.T) {
+	doc, err := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(err, t)
+	expectDocumentString(exampleDocument, doc, t)
+}
+
+func TestParseDocumentStructure(t *testing.T) {
+	doc, err := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(err, t)
+	if !reflect.DeepEqual(doc.Header, []string{"# header comment", ""}) {
+		t.Errorf("unexpected header %#v", doc.Header)
+	}
+	if !reflect.DeepEqual(doc.Footer, []string{"# trailing comment"}) {
+		t.Errorf("unexpected footer %#v", doc.Footer)
+	}
+	if len(doc.Sections) != 3 {
+		t.Fatalf("expected 3 sections, got %d", len(doc.Sections))
+	}
+	database := doc.Sections[1]
+	if database.Name != "database" || database.Line != 9 {
+		t.Errorf("unexpected section %q in line %d", database.Name, database.Line)
+	}
+	if !reflect.DeepEqual(database.Comments, []string{"", "# database settings"}) {
+		t.Errorf("unexpected comments %#v", database.Comments)
+	}
+	host := doc.Sections[0].Properties[0]
+	if host.Name != "host" || host.Value != "localhost" || host.Line != 5 {
+		t.Errorf("unexpected property %#v", host)
+	}
+}
+
+func TestParseDocumentErrorsHaveLineNumbers(t *testing.T) {
+	_, err := NewDocumentFromString("[section]\nfoo = bar\nbroken")
+	expected := ParseError{3, MissingEqualSignError}
+	if err != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+	_, err = NewDocumentFromString("\nfoo = bar\n[section]")
+	if !errors.Is(err, AssignmentOutsideSectionError) {
+		t.Errorf("expected AssignmentOutsideSectionError, got %v", err)
+	}
+}
+
+func TestDocumentConfig(t *testing.T) {
+	doc, err := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(err, t)
+	conf, err := NewConfigFromString(exampleDocument)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(doc.Config(), conf, t)
+}
+
+func TestDocumentGet(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	value, err := doc.Get("server", "port")
+	assertErrorIsNil(err, t)
+	expectValue("8080", value, t)
+	_, err = doc.Get("nosection", "port")
+	if err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	_, err = doc.Get("server", "noproperty")
+	if err != (NoPropertyError{"noproperty"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+}
+
+func TestDocumentGetSectionsAndItems(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	if sections := doc.GetSections(); !reflect.DeepEqual(sections, []string{"server", "database"}) {
+		t.Errorf("unexpected sections %#v", sections)
+	}
+	items, err := doc.GetItems("server")
+	assertErrorIsNil(err, t)
+	expected := []*Item{{"host", "localhost"}, {"port", "8080"}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected %v, got %v", expected, items)
+	}
+}
+
+func TestDocumentSetKeepsUnchangedLines(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(doc.Set("server", "port", "9000"), t)
+	assertErrorIsNil(doc.Set("database", "password", "secret"), t)
+	assertErrorIsNil(doc.Set("server", "host", "localhost"), t)
+	expected := `# header comment
+
+[server]
+; the host to bind to
+host = localhost
+port=80
+
+# database settings
+[database]
+user = root
+password = secret
+[server]
+port = 9000
+# trailing comment
+`
+	expectDocumentString(expected, doc, t)
+	if err := doc.Set("nosection", "a", "b"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
+
+func TestDocumentAddAndRemoveSection(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(doc.RemoveSection("server"), t)
+	assertErrorIsNil(doc.AddSection("new"), t)
+	assertErrorIsNil(doc.Set("new", "a", "b"), t)
+	if err := doc.AddSection("new"); err != DuplicateSectionError {
+		t.Errorf("expected DuplicateSectionError, got %v", err)
+	}
+	if err := doc.RemoveSection("server"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	expected := `# header comment
+
+
+# database settings
+[database]
+user = root
+[new]
+a = b
+# trailing comment
+`
+	expectDocumentString(expected, doc, t)
+}
+
+func TestDocumentRemoveProperty(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(doc.RemoveProperty("server", "port"), t)
+	if doc.HasProperty("server", "port") {
+		t.Error("property port was not removed")
+	}
+	if err := doc.RemoveProperty("server", "port"); err != (NoPropertyError{"port"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+}
+
+func TestDocumentRenameSection(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(doc.RenameSection("server", "http"), t)
+	expected := strings.Replace(exampleDocument, "[server]", "[http]", -1)
+	expectDocumentString(expected, doc, t)
+	if err := doc.RenameSection("server", "x"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	if err := doc.RenameSection("http", "database"); err != DuplicateSectionError {
+		t.Errorf("expected DuplicateSectionError, got %v", err)
+	}
+}
+
+func TestDocumentRenameProperty(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(doc.RenameProperty("server", "port", "listen"), t)
+	expected := strings.Replace(exampleDocument, "port=80", "listen = 80", 1)
+	expected = strings.Replace(expected, "port = 8080", "listen = 8080", 1)
+	expectDocumentString(expected, doc, t)
+	if err := doc.RenameProperty("server", "listen", "host"); err != (DuplicatePropertyError{"host"}) {
+		t.Errorf("expected DuplicatePropertyError, got %v", err)
+	}
+	if err := doc.RenameProperty("server", "port", "x"); err != (NoPropertyError{"port"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+}
+
+func TestDocumentMoveProperty(t *testing.T) {
+	doc, _ := NewDocumentFromString(exampleDocument)
+	assertErrorIsNil(doc.MoveProperty("server", "host", "database"), t)
+	expected := `# header comment
+
+[server]
+port=80
+
+# database settings
+[database]
+user = root
+; the host to bind to
+host = localhost
+[server]
+port = 8080
+# trailing comment
+`
+	expectDocumentString(expected, doc, t)
+	value, err := doc.Get("database", "host")
+	assertErrorIsNil(err, t)
+	expectValue("localhost", value, t)
+	if err := doc.MoveProperty("server", "port", "missing"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	doc.Set("database", "port", "5432")
+	if err := doc.MoveProperty("server", "port", "database"); err != (DuplicatePropertyError{"port"}) {
+		t.Errorf("expected DuplicatePropertyError, got %v", err)
+	}
+}
+
+func TestDocumentNormalize(t *testing.T) {
+	doc, _ := NewDocumentFromString("# comment\n  [section]  \n\tfoo=bar \n\n")
+	doc.Normalize()
+	expectDocumentString("# comment\n[section]\nfoo = bar\n\n", doc, t)
+}
+
+func TestDocumentLineEndings(t *testing.T) {
+	input := "[a]\r\nx = 1\r\n# note\r\ny = 2"
+	doc, err := NewDocumentFromString(input)
+	assertErrorIsNil(err, t)
+	expectDocumentString(input, doc, t)
+	assertErrorIsNil(doc.Set("a", "x", "3"), t)
+	assertErrorIsNil(doc.Set("a", "z", "4"), t)
+	expectDocumentString("[a]\r\nx = 3\r\n# note\r\ny = 2\r\nz = 4", doc, t)
+	doc.Format(&DefaultFormatOptions)
+	expectDocumentString("[a]\r\nx = 3\r\n# note\r\ny = 2\r\nz = 4\r\n", doc, t)
+	doc, _ = NewDocumentFromString("[a]\nx = 1")
+	assertErrorIsNil(doc.Set("a", "x", "2"), t)
+	expectDocumentString("[a]\nx = 2", doc, t)
+	// lines which are not changed keep their own line ending
+	mixed := "# top\r\n\n[a]\r\nx = 1\n# note\n\r\ny = 2\r\n"
+	doc, _ = NewDocumentFromString(mixed)
+	expectDocumentString(mixed, doc, t)
+	expectValues([]string{"top"}, doc.HeaderComment(), t)
+	expectValue("x = 1", doc.Sections[0].Properties[0].String(), t)
+	assertErrorIsNil(doc.Set("a", "y", "3"), t)
+	assertErrorIsNil(doc.SetComment("a", "x", "first"), t)
+	expectDocumentString("# top\r\n\n[a]\r\n# first\r\nx = 1\n# note\n\r\ny = 3\r\n", doc, t)
+	doc.Format(&DefaultFormatOptions)
+	expectDocumentString("# top\r\n\r\n[a]\r\n# first\r\nx = 1\r\n# note\r\n\r\ny = 3\r\n", doc, t)
+}
+
+func TestZeroDocument(t *testing.T) {
+	var doc Document
+	assertErrorIsNil(doc.AddSection("a"), t)
+	assertErrorIsNil(doc.AddSection("b"), t)
+	assertErrorIsNil(doc.Set("a", "x", "1"), t)
+	value, err := doc.Get("a", "x")
+	assertErrorIsNil(err, t)
+	expectValue("1", value, t)
+	doc.Format(&FormatOptions{BlankLines: 1})
+	expectDocumentString("[a]\nx = 1\n\n[b]\n", &doc, t)
+	assertConfigMapsEqual(doc.Config(), &Config{"a": {"x": "1"}, "b": {}}, t)
+	if _, err := (&Document{}).Get("a", "x"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package ini
+
+import (
+	"io"
+	"strings"
+)
+
+// EncodeOptions control how an Encoder writes a file. The zero value writes
+// files the same way as Config.String.
+type EncodeOptions struct {
+	// the text every line ends with. If it is empty, "\n" is used.
+	LineEnding string
+	// the text between a property and its value. If it is empty, " = " is
+	// used. It must contain exactly one equal sign and may be surrounded by
+	// spaces and tabs.
+	Delimiter string
+	// the character every comment is introduced with, either '#' or ';'.
+	// If it is 0, '#' is used.
+	CommentMarker byte
+}
+
+// An Encoder writes sections, properties and comments to an io.Writer one at
+// a time, so that large files can be produced without building a Config in
+// memory first. Section and property names and values are escaped, so that
+// parsing the output gives the same sections and properties again. The
+// output is not buffered; wrap the writer in a bufio.Writer to reduce the
+// number of writes.
+//
+// After the first failed write, all methods return the same error without
+// writing anything. This also holds for an InvalidDelimiterError of the
+// options.
+type Encoder struct {
+	w          io.Writer
+	lineEnding string
+	delimiter  string
+	marker     string
+	// whether a section header was written, see WriteProperty
+	inSection bool
+	// whether names are written without validating them, see Config.String
+	unchecked bool
+	err       error
+}
+
+// Get a new Encoder writing to w. If options is nil, the zero value of
+// EncodeOptions is used. If the delimiter of the options is invalid, every
+// method of the Encoder returns an InvalidDelimiterError.
+func NewEncoder(w io.Writer, options *EncodeOptions) *Encoder {
+	if options == nil {
+		options = &EncodeOptions{}
+	}
+	e := &Encoder{w: w, lineEnding: "\n", delimiter: " = ", marker: "#"}
+	if options.LineEnding != "" {
+		e.lineEnding = options.LineEnding
+	}
+	if options.Delimiter != "" {
+		e.delimiter = options.Delimiter
+		if !isDelimiter(e.delimiter) {
+			e.err = InvalidDelimiterError{e.delimiter}
+		}
+	}
+	if options.CommentMarker != 0 {
+		e.marker = string(options.CommentMarker)
+	}
+	return e
+}
+
+// returns true if the text consists of exactly one equal sign, optionally
+// surrounded by spaces and tabs
+func isDelimiter(text string) bool {
+	return strings.Trim(text, " \t") == "="
+}
+
+// write the line together with the line ending
+func (e *Encoder) writeLine(line string) error {
+	if e.err != nil {
+		return e.err
+	}
+	if _, e.err = io.WriteString(e.w, line); e.err == nil {
+		_, e.err = io.WriteString(e.w, e.lineEnding)
+	}
+	return e.err
+}
+
+// Write the header of a section. All properties written afterwards belong to
+// this section. If the name cannot be written, see ValidateSectionName,
+// InvalidNameError is returned and nothing is written.
+func (e *Encoder) WriteSection(name string) error {
+	if e.err != nil {
+		return e.err
+	}
+	if err := ValidateSectionName(name, nil); err != nil && !e.unchecked {
+		return err
+	}
+	if err := e.writeLine("[" + escapeSectionName(name, nil) + "]"); err != nil {
+		return err
+	}
+	e.inSection = true
+	return nil
+}
+
+// Write an assignment of the value to the property. If no section was
+// written before, AssignmentOutsideSectionError is returned, because the
+// parser would reject the file. If the name cannot be written, see
+// ValidatePropertyName, InvalidNameError is returned and nothing is written.
+func (e *Encoder) WriteProperty(property, value string) error {
+	if e.err != nil {
+		return e.err
+	}
+	if !e.inSection {
+		return AssignmentOutsideSectionError
+	}
+	if err := ValidatePropertyName(property, nil); err != nil && !e.unchecked {
+		return err
+	}
+	return e.writeLine(escapeProperty(property, nil) + e.delimiter + escape(value, nil))
+}
+
+// Write the given lines as a comment. Lines of the text may contain line
+// breaks; empty lines are written as the comment marker alone.
+func (e *Encoder) WriteComment(lines ...string) error {
+	for _, line := range strings.Split(strings.Join(lines, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" {
+			line = " " + line
+		}
+		if err := e.writeLine(e.marker + line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write all sections of the config with their properties. Like in
+// Config.String, the order of the sections and properties is not
+// determined. Sections and properties with invalid names are skipped, the
+// rest of the config is still written; afterwards, the InvalidNameError of
+// the first skipped name is returned.
+func (e *Encoder) Encode(c *Config) error {
+	var invalid error
+	for _, section := range c.GetSections() {
+		err := e.WriteSection(section)
+		if _, ok := err.(InvalidNameError); ok {
+			if invalid == nil {
+				invalid = err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for property, value := range (*c)[section] {
+			err := e.WriteProperty(property, value)
+			if _, ok := err.(InvalidNameError); ok {
+				if invalid == nil {
+					invalid = err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return invalid
+}
+
+// counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Write the config to w in the form of Config.String, but with a line break
+// after the last line. The number of bytes written is returned, so that
+// Config implements io.WriterTo. Invalid names are handled like by
+// Encoder.Encode.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	err := NewEncoder(counter, nil).Encode(c)
+	return counter.n, err
+}
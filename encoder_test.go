@@ -0,0 +1,117 @@
+package ini
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	var buf strings.Builder
+	options := &EncodeOptions{LineEnding: "\r\n", Delimiter: "=", CommentMarker: ';'}
+	e := NewEncoder(&buf, options)
+	if err := e.WriteProperty("a", "b"); err != AssignmentOutsideSectionError {
+		t.Errorf("expected AssignmentOutsideSectionError, got %v", err)
+	}
+	assertErrorIsNil(e.WriteComment("generated file", "", "do not edit"), t)
+	assertErrorIsNil(e.WriteSection("[server]"), t)
+	assertErrorIsNil(e.WriteProperty("host", "localhost"), t)
+	assertErrorIsNil(e.WriteProperty("a=b", " c "), t)
+	expected := "; generated file\r\n;\r\n; do not edit\r\n[\\[server\\]]\r\nhost=localhost\r\na\\=b=\\x20c\\x20\r\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+	conf, err := NewConfigFromString(buf.String())
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"[server]": {"host": "localhost", "a=b": " c "}}, t)
+}
+
+// fails after writing the given number of bytes
+type failingWriter struct {
+	n int
+}
+
+var writeError = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, writeError
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestEncoderError(t *testing.T) {
+	e := NewEncoder(&failingWriter{4}, nil)
+	assertErrorIsNil(e.WriteSection("a"), t)
+	if err := e.WriteProperty("b", "c"); err != writeError {
+		t.Errorf("expected the write error, got %v", err)
+	}
+	if err := e.WriteSection("d"); err != writeError {
+		t.Errorf("expected the write error again, got %v", err)
+	}
+}
+
+func TestConfigWriteTo(t *testing.T) {
+	conf := &Config{"section": {"property": "value"}}
+	var buf strings.Builder
+	n, err := conf.WriteTo(&buf)
+	assertErrorIsNil(err, t)
+	expected := "[section]\nproperty = value\n"
+	if buf.String() != expected || n != int64(len(expected)) {
+		t.Errorf("expected %q, got %q with %d bytes", expected, buf.String(), n)
+	}
+	n, err = conf.WriteTo(&failingWriter{12})
+	if err != writeError || n != 12 {
+		t.Errorf("expected the write error after 12 bytes, got %v after %d bytes", err, n)
+	}
+	expectValue("", NewConfig().String(), t)
+}
+
+func TestEncoderValidation(t *testing.T) {
+	for _, delimiter := range []string{":", "==", " = x", "\n=", "a=b"} {
+		var buf strings.Builder
+		e := NewEncoder(&buf, &EncodeOptions{Delimiter: delimiter})
+		if err := e.WriteSection("a"); err != (InvalidDelimiterError{delimiter}) {
+			t.Errorf("%q: expected InvalidDelimiterError, got %v", delimiter, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%q: expected nothing to be written, got %q", delimiter, buf.String())
+		}
+	}
+	var buf strings.Builder
+	e := NewEncoder(&buf, &EncodeOptions{Delimiter: "\t=  "})
+	if err := e.WriteSection(""); err != (InvalidNameError{"", "the name is empty"}) {
+		t.Errorf("expected InvalidNameError, got %v", err)
+	}
+	if err := e.WriteProperty("a", "b"); err != AssignmentOutsideSectionError {
+		t.Errorf("expected AssignmentOutsideSectionError, got %v", err)
+	}
+	assertErrorIsNil(e.WriteSection("a"), t)
+	if err := e.WriteProperty("", "b"); err != (InvalidNameError{"", "the name is empty"}) {
+		t.Errorf("expected InvalidNameError, got %v", err)
+	}
+	assertErrorIsNil(e.WriteProperty("c", "d"), t)
+	expectValue("[a]\nc\t=  d\n", buf.String(), t)
+}
+
+func TestEncodeInvalidNames(t *testing.T) {
+	conf := &Config{"a": {"": "v", "k": "1"}, "b": {"z": "1"}, "": {"y": "2"}}
+	expected := &Config{"a": {"k": "1"}, "b": {"z": "1"}}
+	var buf strings.Builder
+	_, err := conf.WriteTo(&buf)
+	if err != (InvalidNameError{"", "the name is empty"}) {
+		t.Errorf("expected InvalidNameError, got %v", err)
+	}
+	written, err := NewConfigFromString(buf.String())
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(written, expected, t)
+	s := conf.String()
+	for _, line := range []string{"[a]", " = v", "k = 1", "[b]", "z = 1", "[]", "y = 2"} {
+		if !strings.Contains(s, line) {
+			t.Errorf("expected %q in %q", line, s)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package ini
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var AssignmentOutsideSectionError = errors.New(
@@ -14,6 +15,8 @@ var DuplicateSectionError = errors.New(
 var NoSectionError = errors.New(
 	"attempted to set a property on a section which does not exist")
 
+var MissingPropertyError = errors.New("missing property name")
+
 type NoPropertyError struct {
 	Property string
 }
@@ -21,3 +24,241 @@ type NoPropertyError struct {
 func (error NoPropertyError) Error() string {
 	return fmt.Sprintf("No such property %q", error.Property)
 }
+
+type DuplicatePropertyError struct {
+	Property string
+}
+
+func (error DuplicatePropertyError) Error() string {
+	return fmt.Sprintf("Property %q already exists", error.Property)
+}
+
+// A PatchSyntaxError is returned by ParsePatch if a line of the patch cannot
+// be parsed.
+type PatchSyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (error PatchSyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", error.Line, error.Message)
+}
+
+// A PatchConflictError is returned when a patch expects a property to have a
+// different value than it actually has.
+type PatchConflictError struct {
+	Section  string
+	Property string
+	Expected string
+	Actual   string
+}
+
+func (error PatchConflictError) Error() string {
+	return fmt.Sprintf("property %q in section %q has the value %q, expected %q",
+		error.Property, error.Section, error.Actual, error.Expected)
+}
+
+// A PatchError is returned if a step of a patch cannot be applied. Err holds
+// the reason, e.g. NoSectionError or a PatchConflictError.
+type PatchError struct {
+	Index int
+	Step  PatchStep
+	Err   error
+}
+
+func (error PatchError) Error() string {
+	return fmt.Sprintf("patch step %d (%s): %v", error.Index+1, error.Step, error.Err)
+}
+
+func (error PatchError) Unwrap() error {
+	return error.Err
+}
+
+// A ParseError is returned by the Document constructors and the Decoder if a
+// line cannot be parsed. Err is the reason, e.g. MissingEqualSignError. The
+// Config constructors return it for all syntax errors except
+// MissingEqualSignError, TooManyEqualSignsError and
+// AssignmentOutsideSectionError.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (error ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", error.Line, error.Err)
+}
+
+func (error ParseError) Unwrap() error {
+	return error.Err
+}
+
+var UnsupportedPolicyError = errors.New(
+	"DuplicateKeepAll can only be used when parsing a Document")
+
+// A DuplicateDefinitionError is returned by the parser if a section or a
+// property is defined twice and the policy is DuplicateError. Property is
+// empty if the section was declared twice.
+type DuplicateDefinitionError struct {
+	Section    string
+	Property   string
+	FirstLine  int
+	SecondLine int
+}
+
+func (error DuplicateDefinitionError) Error() string {
+	if error.Property == "" {
+		return fmt.Sprintf("section %q declared in line %d and again in line %d",
+			error.Section, error.FirstLine, error.SecondLine)
+	}
+	return fmt.Sprintf("property %q of section %q assigned in line %d and again in line %d",
+		error.Property, error.Section, error.FirstLine, error.SecondLine)
+}
+
+// A NoValueError is returned by Document.RemoveValue if the property never
+// has the value which should be removed.
+type NoValueError struct {
+	Property string
+	Value    string
+}
+
+func (error NoValueError) Error() string {
+	return fmt.Sprintf("property %q has no value %q", error.Property, error.Value)
+}
+
+var InvalidDecodeTargetError = errors.New(
+	"the target of Decode must be a non-nil pointer")
+
+// A DecodeError is returned by Config.Decode if a value cannot be stored in
+// the field it belongs to. Property is empty if the section itself cannot be
+// decoded.
+type DecodeError struct {
+	Section  string
+	Property string
+	Err      error
+}
+
+func (error DecodeError) Error() string {
+	if error.Property == "" {
+		return fmt.Sprintf("section %q: %v", error.Section, error.Err)
+	}
+	return fmt.Sprintf("property %q of section %q: %v", error.Property, error.Section, error.Err)
+}
+
+func (error DecodeError) Unwrap() error {
+	return error.Err
+}
+
+// A SubsectionSyntaxError is returned if the quoted subsection of a section
+// name like remote "origin" cannot be parsed.
+type SubsectionSyntaxError struct {
+	Name    string
+	Message string
+}
+
+func (error SubsectionSyntaxError) Error() string {
+	return fmt.Sprintf("section %q: %s", error.Name, error.Message)
+}
+
+var EmptyParentError = errors.New("empty name of a parent section")
+
+// An InheritanceCycleError is returned if a section inherits from itself,
+// directly or through other sections. Sections lists the cycle, starting and
+// ending with the same section.
+type InheritanceCycleError struct {
+	Sections []string
+}
+
+func (error InheritanceCycleError) Error() string {
+	return fmt.Sprintf("inheritance cycle: %s", strings.Join(error.Sections, " -> "))
+}
+
+// A MissingParentError is returned if a section inherits from a section which
+// does not exist.
+type MissingParentError struct {
+	Section string
+	Parent  string
+}
+
+func (error MissingParentError) Error() string {
+	return fmt.Sprintf("section %q inherits from the missing section %q", error.Section, error.Parent)
+}
+
+// A NoRecordError is returned if a section is declared fewer times than the
+// index of a record requires.
+type NoRecordError struct {
+	Section string
+	Index   int
+}
+
+func (error NoRecordError) Error() string {
+	return fmt.Sprintf("section %q has no record %d", error.Section, error.Index)
+}
+
+var IndexOutOfRangeError = errors.New("index out of range")
+
+// An InvalidEscapeError is returned if a backslash is followed by an unknown
+// or incomplete escape sequence. Column is the position of the backslash in
+// the line, starting at 1.
+type InvalidEscapeError struct {
+	Column   int
+	Sequence string
+}
+
+func (error InvalidEscapeError) Error() string {
+	return fmt.Sprintf("column %d: invalid escape sequence %q", error.Column, error.Sequence)
+}
+
+// An InvalidNameError is returned if a section or a property cannot be
+// written with the given name, see ValidateSectionName and
+// ValidatePropertyName.
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+func (error InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid name %q: %s", error.Name, error.Reason)
+}
+
+// An UnsupportedInlineCommentError is returned by Document.SetInlineComment
+// if the comment marker of the document is not one of the
+// InlineCommentPrefixes the document was parsed with, so that the comment
+// would not be read back as a comment.
+type UnsupportedInlineCommentError struct {
+	Marker string
+}
+
+func (error UnsupportedInlineCommentError) Error() string {
+	return fmt.Sprintf("%q is not an inline comment prefix of the document", error.Marker)
+}
+
+// An InvalidDelimiterError is returned by the methods of an Encoder if
+// EncodeOptions.Delimiter does not consist of exactly one equal sign,
+// optionally surrounded by spaces and tabs.
+type InvalidDelimiterError struct {
+	Delimiter string
+}
+
+func (error InvalidDelimiterError) Error() string {
+	return fmt.Sprintf("invalid delimiter %q", error.Delimiter)
+}
+
+// A SourceError is returned by LoadConfig and LoadDocument if the file cannot
+// be parsed. Line is the number of the offending line, or 0 if the error does
+// not belong to a line, e.g. a MissingParentError.
+type SourceError struct {
+	Source string
+	Line   int
+	Err    error
+}
+
+func (error SourceError) Error() string {
+	if error.Line == 0 {
+		return fmt.Sprintf("%s: %v", error.Source, error.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", error.Source, error.Line, error.Err)
+}
+
+func (error SourceError) Unwrap() error {
+	return error.Err
+}
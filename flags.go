@@ -0,0 +1,30 @@
+package ini
+
+// Returns true if the given property of the section exists and was written
+// without a value, e.g. skip-networking in my.cnf. Such properties are only
+// accepted with ParseOptions.AllowNoValue. If the property is assigned
+// several times, its last assignment decides.
+func (d *Document) IsFlag(section, property string) bool {
+	p, err := d.lastProperty(section, property)
+	return err == nil && p.NoValue
+}
+
+// Returns true if the given property of the section exists and was assigned a
+// value, which may be the empty string.
+func (d *Document) HasValue(section, property string) bool {
+	return d.HasProperty(section, property) && !d.IsFlag(section, property)
+}
+
+// Set the given property in the given section as a flag without a value. If
+// the property exists, its last assignment is replaced; otherwise the flag is
+// appended to the last declaration of the section. Attempting to set flags in
+// non-existing sections will return NoSectionError.
+func (d *Document) SetFlag(section, property string) error {
+	if err := d.Set(section, property, ""); err != nil {
+		return err
+	}
+	p, _ := d.lastProperty(section, property)
+	p.NoValue = true
+	p.raw = ""
+	return nil
+}
@@ -0,0 +1,59 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+const mycnf = `[mysqld]
+skip-networking
+port = 3306
+empty =
+`
+
+var noValueOptions = &ParseOptions{AllowNoValue: true}
+
+func TestParseConfigAllowNoValue(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(mycnf), noValueOptions)
+	assertErrorIsNil(err, t)
+	expected := &Config{"mysqld": {"skip-networking": "", "port": "3306", "empty": ""}}
+	assertConfigMapsEqual(conf, expected, t)
+	_, err = NewConfigFromString(mycnf)
+	if err != MissingEqualSignError {
+		t.Errorf("expected MissingEqualSignError, got %v", err)
+	}
+	_, err = ParseConfig(strings.NewReader("[mysqld\n"), noValueOptions)
+	if err != MissingEqualSignError {
+		t.Errorf("expected MissingEqualSignError for a broken header, got %v", err)
+	}
+}
+
+func TestDocumentFlags(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(mycnf), noValueOptions)
+	assertErrorIsNil(err, t)
+	if !doc.IsFlag("mysqld", "skip-networking") || doc.HasValue("mysqld", "skip-networking") {
+		t.Error("skip-networking should be a flag")
+	}
+	if doc.IsFlag("mysqld", "empty") || !doc.HasValue("mysqld", "empty") {
+		t.Error("empty should have an empty value")
+	}
+	if doc.IsFlag("mysqld", "missing") || doc.HasValue("mysqld", "missing") {
+		t.Error("missing should not exist")
+	}
+	value, err := doc.Get("mysqld", "skip-networking")
+	assertErrorIsNil(err, t)
+	expectValue("", value, t)
+}
+
+func TestWriteFlags(t *testing.T) {
+	doc, _ := ParseDocument(strings.NewReader(mycnf), noValueOptions)
+	assertErrorIsNil(doc.SetFlag("mysqld", "skip-grant-tables"), t)
+	assertErrorIsNil(doc.Set("mysqld", "skip-networking", "1"), t)
+	assertErrorIsNil(doc.SetFlag("mysqld", "port"), t)
+	expectDocumentString("[mysqld]\nskip-networking = 1\nport\nempty =\nskip-grant-tables\n", doc, t)
+	if err := doc.SetFlag("missing", "flag"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	doc.Format(&DefaultFormatOptions)
+	expectDocumentString("[mysqld]\nskip-networking = 1\nport\nempty =\nskip-grant-tables\n", doc, t)
+}
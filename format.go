@@ -0,0 +1,161 @@
+package ini
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatOptions control how Format and Document.Format lay out a document.
+type FormatOptions struct {
+	// pad the names of all properties of a section to the same width, so
+	// that the equal signs and values are aligned
+	AlignValues bool
+	// sort the properties of each section by name. Comments preceding a
+	// property are moved together with it.
+	SortKeys bool
+	// the character every comment is introduced with, either '#' or ';'.
+	// If it is 0, the comment markers are kept. Inline comments only get
+	// the new marker if it is one of the InlineCommentPrefixes of the
+	// document, so that they are still read as comments.
+	CommentMarker byte
+	// the number of blank lines between two sections
+	BlankLines int
+}
+
+// The options used by the ini and inifmt commands if no other options are
+// given.
+var DefaultFormatOptions = FormatOptions{BlankLines: 1}
+
+// Format the given ini file. See Document.Format for the applied rules.
+func Format(s string, options *FormatOptions) (string, error) {
+	doc, err := NewDocumentFromString(s)
+	if err != nil {
+		return s, err
+	}
+	doc.Format(options)
+	return doc.String(), nil
+}
+
+// Rewrite the document in its canonical form. Section headers and assignments
+// are normalized like in Normalize, optionally aligned and sorted. Leading
+// whitespace is removed from comments and trailing whitespace from all lines.
+// Consecutive blank lines are collapsed into one, blank lines directly after
+// a section header and at the end of the file are removed and sections are
+// separated by exactly options.BlankLines blank lines. The file header stays
+// separated from the first section by at least one blank line. All lines get
+// the LineEnding of the document, and the last line is always terminated.
+func (d *Document) Format(options *FormatOptions) {
+	d.Normalize()
+	d.NoFinalNewline = false
+	prefixes := d.parseOptions().InlineCommentPrefixes
+	blankLines := make([]string, options.BlankLines)
+	d.Header = trimBlankLines(formatComments(d.Header, options, prefixes))
+	for i, section := range d.Sections {
+		comments := trimBlankLines(formatComments(section.Comments, options, prefixes))
+		if i > 0 {
+			comments = append(blankLines[:len(blankLines):len(blankLines)], comments...)
+		} else if len(d.Header) > 0 {
+			d.Header = append(d.Header, blankLines...)
+			if options.BlankLines == 0 {
+				d.Header = append(d.Header, "")
+			}
+		}
+		section.Comments = comments
+		section.InlineComment = formatInlineComment(section.InlineComment, options, prefixes)
+		if options.SortKeys {
+			sort.SliceStable(section.Properties, func(i, j int) bool {
+				return section.Properties[i].Name < section.Properties[j].Name
+			})
+		}
+		width := 0
+		for _, property := range section.Properties {
+			if n := utf8.RuneCountInString(property.key(prefixes)); n > width {
+				width = n
+			}
+		}
+		for j, property := range section.Properties {
+			property.Comments = formatComments(property.Comments, options, prefixes)
+			if j == 0 {
+				property.Comments = trimLeadingBlankLines(property.Comments)
+			}
+			property.InlineComment = formatInlineComment(property.InlineComment, options, prefixes)
+			if !options.AlignValues {
+				width = 0
+			}
+			property.raw = strings.TrimRight(property.format(width, prefixes), " \t")
+		}
+	}
+	d.Footer = trimTrailingBlankLines(formatComments(d.Footer, options, prefixes))
+	d.terminateLines()
+}
+
+// Give all comments, blank lines and formatted assignments the line ending of
+// the document, see storedLine.
+func (d *Document) terminateLines() {
+	terminate := func(lines []string) []string {
+		terminated := make([]string, len(lines))
+		for i, line := range lines {
+			terminated[i] = d.storedLine(line)
+		}
+		return terminated
+	}
+	d.Header = terminate(d.Header)
+	for _, section := range d.Sections {
+		section.Comments = terminate(section.Comments)
+		for _, property := range section.Properties {
+			property.Comments = terminate(property.Comments)
+			property.raw = d.storedLine(property.raw)
+		}
+	}
+	d.Footer = terminate(d.Footer)
+}
+
+// Change the marker of the inline comment, unless the parser would not
+// recognize the new marker, see Document.SetInlineComment.
+func formatInlineComment(comment string, options *FormatOptions, prefixes []string) string {
+	if comment == "" || options.CommentMarker == 0 || !isInlineCommentPrefix(string(options.CommentMarker), prefixes) {
+		return comment
+	}
+	return changeCommentMarker(comment, options.CommentMarker, prefixes)
+}
+
+// replace the marker of the comment, see commentPrefix
+func changeCommentMarker(comment string, marker byte, prefixes []string) string {
+	return string(marker) + comment[len(commentPrefix(comment, prefixes)):]
+}
+
+// Strip whitespace from comments and blank lines, change the comment markers
+// and collapse consecutive blank lines.
+func formatComments(lines []string, options *FormatOptions, prefixes []string) []string {
+	formatted := []string{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" && len(formatted) > 0 && formatted[len(formatted)-1] == "" {
+			continue
+		}
+		if line != "" && options.CommentMarker != 0 {
+			line = changeCommentMarker(line, options.CommentMarker, prefixes)
+		}
+		formatted = append(formatted, line)
+	}
+	return formatted
+}
+
+func trimLeadingBlankLines(lines []string) []string {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+func trimTrailingBlankLines(lines []string) []string {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func trimBlankLines(lines []string) []string {
+	return trimTrailingBlankLines(trimLeadingBlankLines(lines))
+}
@@ -0,0 +1,103 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+const unformattedDocument = `  # header comment
+
+
+   [server]
+
+host=localhost
+  ; the port
+   port   =   80
+
+
+[database]
+	user =
+name = db
+; trailing comment
+
+
+`
+
+func expectFormatted(expected, input string, options *FormatOptions, t *testing.T) {
+	formatted, err := Format(input, options)
+	assertErrorIsNil(err, t)
+	if formatted != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, formatted)
+	}
+	again, err := Format(formatted, options)
+	assertErrorIsNil(err, t)
+	if again != formatted {
+		t.Errorf("formatting is not idempotent:\n%s\nbecame\n%s", formatted, again)
+	}
+}
+
+func TestFormatDefaults(t *testing.T) {
+	expected := `# header comment
+
+[server]
+host = localhost
+; the port
+port = 80
+
+[database]
+user =
+name = db
+; trailing comment
+`
+	expectFormatted(expected, unformattedDocument, &DefaultFormatOptions, t)
+}
+
+func TestFormatAlignSortAndMarker(t *testing.T) {
+	options := &FormatOptions{AlignValues: true, SortKeys: true, CommentMarker: '#', BlankLines: 2}
+	expected := `# header comment
+
+
+[server]
+host = localhost
+# the port
+port = 80
+
+
+[database]
+name = db
+user =
+# trailing comment
+`
+	expectFormatted(expected, unformattedDocument, options, t)
+}
+
+func TestFormatAlignValues(t *testing.T) {
+	options := &FormatOptions{AlignValues: true}
+	expected := "[section]\na      = 1\nlonger = 2\nμμ     = 3\n"
+	expectFormatted(expected, "[section]\na=1\nlonger=2\nμμ = 3", options, t)
+}
+
+func TestFormatWithoutBlankLinesKeepsHeader(t *testing.T) {
+	options := &FormatOptions{}
+	expected := "; header\n\n[a]\nx = 1\n[b]\n"
+	expectFormatted(expected, "; header\n\n[a]\nx=1\n\n[b]", options, t)
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	_, err := Format("[section]\nbroken", &DefaultFormatOptions)
+	if err != (ParseError{2, MissingEqualSignError}) {
+		t.Errorf("expected ParseError, got %v", err)
+	}
+}
+
+func TestFormatKeepsUnrecognizedInlineMarker(t *testing.T) {
+	options := &ParseOptions{InlineCommentPrefixes: []string{"#"}}
+	doc, err := ParseDocument(strings.NewReader("# top\n\n[a] # hdr\nk = v # note\n"), options)
+	assertErrorIsNil(err, t)
+	doc.Format(&FormatOptions{CommentMarker: ';'})
+	expectDocumentString("; top\n\n[a] # hdr\nk = v # note\n", doc, t)
+	reparsed, err := ParseDocument(strings.NewReader(doc.String()), options)
+	assertErrorIsNil(err, t)
+	value, _ := reparsed.Get("a", "k")
+	expectValue("v", value, t)
+}
@@ -0,0 +1,255 @@
+package ini
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The separator between the levels of hierarchical section names like
+// [server.http], used if an empty separator is passed.
+const DefaultSectionSeparator = "."
+
+func sectionSeparator(separator string) string {
+	if separator == "" {
+		return DefaultSectionSeparator
+	}
+	return separator
+}
+
+// Return the name of the child section below the parent, e.g. server.http.
+func joinSection(parent, child, separator string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + separator + child
+}
+
+// If the section lies below the parent, return the rest of its name after the
+// parent and the separator.
+func relativeSection(section, parent, separator string) (string, bool) {
+	if parent == "" {
+		return section, true
+	}
+	if !strings.HasPrefix(section, parent+separator) {
+		return "", false
+	}
+	return section[len(parent)+len(separator):], true
+}
+
+// Return the names of the direct children of the given section in sorted
+// order, e.g. http and grpc for the parent server if the config contains the
+// sections server.http and server.grpc.tls. The parent does not have to be
+// declared itself. For an empty parent, the names of the top level are
+// returned. If the separator is empty, DefaultSectionSeparator is used.
+func (c *Config) Subsections(parent, separator string) []string {
+	separator = sectionSeparator(separator)
+	children := []string{}
+	seen := make(map[string]bool)
+	for section := range *c {
+		rest, ok := relativeSection(section, parent, separator)
+		if !ok {
+			continue
+		}
+		child := strings.SplitN(rest, separator, 2)[0]
+		if child != "" && !seen[child] {
+			seen[child] = true
+			children = append(children, child)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Return a copy of all sections below the given one with the name of the
+// parent and the separator removed, i.e. the section server.http becomes http
+// in the scope of server. The properties of the parent itself are not part of
+// the scope. If the separator is empty, DefaultSectionSeparator is used.
+func (c *Config) Scope(parent, separator string) *Config {
+	separator = sectionSeparator(separator)
+	scope := NewConfig()
+	for section, properties := range *c {
+		rest, ok := relativeSection(section, parent, separator)
+		if !ok || rest == "" {
+			continue
+		}
+		scope.AddSection(rest)
+		for property, value := range properties {
+			scope.Set(rest, property, value)
+		}
+	}
+	return scope
+}
+
+// Decode the hierarchy of sections into v, which must be a non-nil pointer to
+// a struct or a map. The fields of a struct are filled as follows:
+//
+//   - strings, booleans and numbers are read from the property of the same
+//     name in the current section
+//   - structs and pointers to structs are decoded from the child section of
+//     the same name, e.g. the field HTTP of the struct for [server] from
+//     [server.http]
+//   - maps from strings to strings or numbers receive all properties of the
+//     child section
+//   - other maps with string keys receive one entry for every child of the
+//     child section
+//
+// The name can be changed with a tag like `ini:"http"`; names from tags must
+// match exactly, field names regardless of case. Fields tagged with `ini:"-"`
+// and unexported fields are ignored, as are fields without a matching
+// property or section. If the separator is empty, DefaultSectionSeparator is
+// used. If a value cannot be converted, a DecodeError is returned.
+func (c *Config) Decode(v interface{}, separator string) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return InvalidDecodeTargetError
+	}
+	d := decoder{c, sectionSeparator(separator)}
+	return d.section("", target.Elem())
+}
+
+type decoder struct {
+	config    *Config
+	separator string
+}
+
+// decode the section with the given name into v
+func (d decoder) section(name string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.section(name, v.Elem())
+	case reflect.Struct:
+		return d.structure(name, v)
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return d.mapping(name, v)
+		}
+	}
+	return DecodeError{name, "", fmt.Errorf("cannot decode a section into %s", v.Type())}
+}
+
+func (d decoder) structure(name string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("ini")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		key := field.Name
+		if tag != "" {
+			key = tag
+		}
+		if isScalar(field.Type) {
+			property, ok := matchName(sortedKeys((*d.config)[name]), key, tag != "")
+			if !ok {
+				continue
+			}
+			if err := setScalar(v.Field(i), (*d.config)[name][property]); err != nil {
+				return DecodeError{name, property, err}
+			}
+			continue
+		}
+		child, ok := matchName(d.config.Subsections(name, d.separator), key, tag != "")
+		if !ok {
+			continue
+		}
+		if err := d.section(joinSection(name, child, d.separator), v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d decoder) mapping(name string, v reflect.Value) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	keyType, elemType := v.Type().Key(), v.Type().Elem()
+	if isScalar(elemType) {
+		for property, value := range (*d.config)[name] {
+			elem := reflect.New(elemType).Elem()
+			if err := setScalar(elem, value); err != nil {
+				return DecodeError{name, property, err}
+			}
+			v.SetMapIndex(reflect.ValueOf(property).Convert(keyType), elem)
+		}
+		return nil
+	}
+	for _, child := range d.config.Subsections(name, d.separator) {
+		elem := reflect.New(elemType).Elem()
+		if err := d.section(joinSection(name, child, d.separator), elem); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(child).Convert(keyType), elem)
+	}
+	return nil
+}
+
+// Return the name which matches the key. If exact is false, the case of the
+// names is ignored, but an exact match is preferred.
+func matchName(names []string, key string, exact bool) (string, bool) {
+	for _, name := range names {
+		if name == key {
+			return name, true
+		}
+	}
+	if !exact {
+		for _, name := range names {
+			if strings.EqualFold(name, key) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Returns true if values of the given type are read from a single property.
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// convert the value to the type of v and store it in v
+func setScalar(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
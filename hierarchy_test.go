@@ -0,0 +1,118 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const hierarchyConfig = `[server]
+name = example
+[server.http]
+port = 80
+enabled = true
+[server.grpc]
+port = 9090
+[server.grpc.tls]
+cert = /etc/cert.pem
+[database/primary]
+host = db1
+`
+
+type tlsConfig struct {
+	Cert string
+}
+
+type listenerConfig struct {
+	Port    uint16
+	Enabled bool
+	TLS     *tlsConfig
+}
+
+type serverConfig struct {
+	Name string
+	HTTP listenerConfig
+	GRPC *listenerConfig `ini:"grpc"`
+	Tags string          `ini:"-"`
+}
+
+func TestSubsections(t *testing.T) {
+	conf, _ := NewConfigFromString(hierarchyConfig)
+	expectValues([]string{"grpc", "http"}, conf.Subsections("server", ""), t)
+	expectValues([]string{"tls"}, conf.Subsections("server.grpc", "."), t)
+	expectValues([]string{}, conf.Subsections("server.http", ""), t)
+	expectValues([]string{"database/primary", "server"}, conf.Subsections("", ""), t)
+	expected := []string{"database", "server", "server.grpc", "server.grpc.tls", "server.http"}
+	expectValues(expected, conf.Subsections("", "/"), t)
+	expectValues([]string{"primary"}, conf.Subsections("database", "/"), t)
+}
+
+func TestScope(t *testing.T) {
+	conf, _ := NewConfigFromString(hierarchyConfig)
+	expected := &Config{
+		"http":     {"port": "80", "enabled": "true"},
+		"grpc":     {"port": "9090"},
+		"grpc.tls": {"cert": "/etc/cert.pem"}}
+	assertConfigMapsEqual(conf.Scope("server", ""), expected, t)
+	assertConfigMapsEqual(conf.Scope("database", "/"), &Config{"primary": {"host": "db1"}}, t)
+	assertConfigMapsEqual(conf.Scope("missing", ""), &Config{}, t)
+}
+
+func TestDecodeStruct(t *testing.T) {
+	conf, _ := NewConfigFromString(hierarchyConfig)
+	var config struct {
+		Server   serverConfig
+		Database map[string]map[string]string `ini:"database"`
+	}
+	assertErrorIsNil(conf.Decode(&config, ""), t)
+	expected := serverConfig{
+		Name: "example",
+		HTTP: listenerConfig{Port: 80, Enabled: true},
+		GRPC: &listenerConfig{Port: 9090, TLS: &tlsConfig{"/etc/cert.pem"}}}
+	if !reflect.DeepEqual(config.Server, expected) {
+		t.Errorf("expected %+v, got %+v", expected, config.Server)
+	}
+	if config.Database != nil {
+		t.Errorf("expected no database sections with the separator ., got %v", config.Database)
+	}
+	assertErrorIsNil(conf.Decode(&config, "/"), t)
+	if host := config.Database["primary"]["host"]; host != "db1" {
+		t.Errorf("expected the host db1, got %q", host)
+	}
+}
+
+func TestDecodeMap(t *testing.T) {
+	conf, _ := NewConfigFromString(hierarchyConfig)
+	listeners := map[string]listenerConfig{}
+	scope := conf.Scope("server", "")
+	assertErrorIsNil(scope.Decode(&listeners, ""), t)
+	if len(listeners) != 2 || listeners["http"].Port != 80 || listeners["grpc"].TLS == nil {
+		t.Errorf("unexpected listeners %+v", listeners)
+	}
+	files := map[string]map[string]string{}
+	assertErrorIsNil(conf.Scope("server.grpc", "").Decode(&files, ""), t)
+	if !reflect.DeepEqual(files, map[string]map[string]string{"tls": {"cert": "/etc/cert.pem"}}) {
+		t.Errorf("unexpected files %v", files)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	conf, _ := NewConfigFromString("[server.http]\nport = eighty\n")
+	var config struct{ Server struct{ HTTP listenerConfig } }
+	if err := conf.Decode(config, ""); err != InvalidDecodeTargetError {
+		t.Errorf("expected InvalidDecodeTargetError, got %v", err)
+	}
+	err := conf.Decode(&config, "")
+	var decodeError DecodeError
+	if !errors.As(err, &decodeError) || decodeError.Section != "server.http" || decodeError.Property != "port" {
+		t.Fatalf("expected a DecodeError for server.http, got %v", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected the error to wrap strconv.ErrSyntax, got %v", err)
+	}
+	var wrong struct{ Server []string }
+	if err := conf.Decode(&wrong, ""); err == nil {
+		t.Error("expected an error when decoding a section into a slice")
+	}
+}
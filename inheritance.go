@@ -0,0 +1,170 @@
+package ini
+
+import "strings"
+
+// An Inheritance maps the names of sections to the names of the sections they
+// inherit from, in the order in which they are searched.
+type Inheritance map[string][]string
+
+// add the parents of the section unless they were declared before
+func (i Inheritance) add(section string, parents []string) {
+	for _, parent := range parents {
+		known := false
+		for _, p := range i[section] {
+			known = known || p == parent
+		}
+		if !known {
+			i[section] = append(i[section], parent)
+		}
+	}
+}
+
+// Split the name of a section header like staging : production, defaults
+// into the name of the section and its parents. Escaped colons and colons
+// within quotes do not count, so that subsections like remote "c:/repo" can
+// be used as well. The names are returned with their escape sequences.
+func splitParents(header string) (name string, parents []string, err error) {
+	colon := -1
+	quoted := false
+	for i := 0; i < len(header) && colon < 0; i++ {
+		switch header[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return header, nil, nil
+	}
+	for _, parent := range strings.Split(header[colon+1:], ",") {
+		parent = strings.TrimSpace(parent)
+		if parent == "" {
+			return "", nil, EmptyParentError
+		}
+		parents = append(parents, parent)
+	}
+	return strings.TrimSpace(header[:colon]), parents, nil
+}
+
+// Return the sections which are searched for the properties of the given
+// section, starting with the section itself. The parents are searched
+// depth-first in the order they are listed; a section which can be reached in
+// several ways is only searched the first time. If the section does not
+// exist, NoSectionError is returned. If a parent does not exist,
+// MissingParentError is returned, and InheritanceCycleError if a section
+// inherits from itself.
+func (c *Config) lookupOrder(section string, inheritance Inheritance) ([]string, error) {
+	if !c.HasSection(section) {
+		return nil, NoSectionError
+	}
+	order := []string{}
+	seen := make(map[string]bool)
+	var visit func(section string, path []string) error
+	visit = func(section string, path []string) error {
+		for i, s := range path {
+			if s == section {
+				cycle := append([]string{}, path[i:]...)
+				return InheritanceCycleError{append(cycle, section)}
+			}
+		}
+		if seen[section] {
+			return nil
+		}
+		seen[section] = true
+		order = append(order, section)
+		path = append(path[:len(path):len(path)], section)
+		for _, parent := range inheritance[section] {
+			if !c.HasSection(parent) {
+				return MissingParentError{section, parent}
+			}
+			if err := visit(parent, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(section, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Return the sections the given section inherits from, directly or
+// indirectly, in the order in which they are searched by Lookup. The errors
+// are the same as for Lookup.
+func (c *Config) Ancestors(section string, inheritance Inheritance) ([]string, error) {
+	order, err := c.lookupOrder(section, inheritance)
+	if err != nil {
+		return []string{}, err
+	}
+	return order[1:], nil
+}
+
+// Get the value of the passed property in the given section. If the section
+// does not define the property, its parents are searched, see Ancestors.
+// Besides the value, the name of the section which defines it is returned.
+// If the section does not exist, NoSectionError is returned. If neither the
+// section nor one of its ancestors defines the property, NoPropertyError is
+// returned. MissingParentError and InheritanceCycleError are returned if the
+// inheritance is broken.
+func (c *Config) Lookup(section, property string, inheritance Inheritance) (value, origin string, err error) {
+	order, err := c.lookupOrder(section, inheritance)
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range order {
+		if value, err := c.Get(s, property); err == nil {
+			return value, s, nil
+		}
+	}
+	return "", "", NoPropertyError{property}
+}
+
+// Return a copy of the config in which every section contains the properties
+// it inherits from its ancestors, unless it defines them itself. The errors
+// are the same as for Lookup.
+func (c *Config) Flatten(inheritance Inheritance) (*Config, error) {
+	flat := NewConfig()
+	for section := range *c {
+		order, err := c.lookupOrder(section, inheritance)
+		if err != nil {
+			return c, err
+		}
+		flat.AddSection(section)
+		for i := len(order) - 1; i >= 0; i-- {
+			for property, value := range (*c)[order[i]] {
+				flat.Set(section, property, value)
+			}
+		}
+	}
+	return flat, nil
+}
+
+// Return the parents of all sections of the document. If a section is
+// declared several times, the parents of all declarations are combined. The
+// names are spelled like in Document.Config, so that the result can be used
+// with its Lookup. If a parent does not exist, MissingParentError is
+// returned, and InheritanceCycleError if a section inherits from itself.
+func (d *Document) Inheritance() (Inheritance, error) {
+	inheritance := make(Inheritance)
+	names := d.spellings()
+	for _, section := range d.Sections {
+		parents := make([]string, len(section.Parents))
+		for i, parent := range section.Parents {
+			parents[i] = names.section(parent)
+		}
+		inheritance.add(names.section(section.Name), parents)
+	}
+	conf := d.Config()
+	for _, section := range d.GetSections() {
+		if _, err := conf.lookupOrder(names.section(section), inheritance); err != nil {
+			return inheritance, err
+		}
+	}
+	return inheritance, nil
+}
@@ -0,0 +1,154 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var inheritanceOptions = &ParseOptions{Inheritance: true}
+
+const inheritanceDocument = `[defaults]
+timeout = 30
+log = info
+[production]
+host = db.example.com
+log = warn
+[staging : production, defaults]
+host = staging.example.com
+[development:staging]
+log = debug
+`
+
+func TestSplitParents(t *testing.T) {
+	cases := []struct {
+		header  string
+		name    string
+		parents []string
+	}{
+		{"staging", "staging", nil},
+		{"staging : production", "staging", []string{"production"}},
+		{"a:b , c", "a", []string{"b", "c"}},
+		{`remote "c:/repo" : defaults`, `remote "c:/repo"`, []string{"defaults"}},
+		{`remote "a\":b"`, `remote "a\":b"`, nil}}
+	for _, c := range cases {
+		name, parents, err := splitParents(c.header)
+		assertErrorIsNil(err, t)
+		if name != c.name || !reflect.DeepEqual(parents, c.parents) {
+			t.Errorf("%q: expected %q and %q, got %q and %q", c.header, c.name, c.parents, name, parents)
+		}
+	}
+	for _, header := range []string{"a :", "a : b,", "a : , b"} {
+		if _, _, err := splitParents(header); err != EmptyParentError {
+			t.Errorf("%q: expected EmptyParentError, got %v", header, err)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(inheritanceDocument), inheritanceOptions)
+	assertErrorIsNil(err, t)
+	inheritance, err := doc.Inheritance()
+	assertErrorIsNil(err, t)
+	conf := doc.Config()
+	cases := []struct {
+		section, property, value, origin string
+	}{
+		{"development", "log", "debug", "development"},
+		{"development", "host", "staging.example.com", "staging"},
+		{"staging", "log", "warn", "production"},
+		{"staging", "timeout", "30", "defaults"},
+		{"production", "host", "db.example.com", "production"}}
+	for _, c := range cases {
+		value, origin, err := conf.Lookup(c.section, c.property, inheritance)
+		assertErrorIsNil(err, t)
+		if value != c.value || origin != c.origin {
+			t.Errorf("%s.%s: expected %q from %s, got %q from %s",
+				c.section, c.property, c.value, c.origin, value, origin)
+		}
+	}
+	if _, _, err := conf.Lookup("production", "timeout", inheritance); err != (NoPropertyError{"timeout"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+	if _, _, err := conf.Lookup("missing", "timeout", inheritance); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	ancestors, err := conf.Ancestors("development", inheritance)
+	assertErrorIsNil(err, t)
+	expectValues([]string{"staging", "production", "defaults"}, ancestors, t)
+	expectDocumentString(inheritanceDocument, doc, t)
+	doc.Normalize()
+	if !strings.Contains(doc.String(), "[development : staging]\n") {
+		t.Errorf("expected the parents to be written, got %q", doc.String())
+	}
+}
+
+func TestParseConfigInheritance(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(inheritanceDocument), inheritanceOptions)
+	assertErrorIsNil(err, t)
+	expected := &Config{
+		"defaults":    {"timeout": "30", "log": "info"},
+		"production":  {"host": "db.example.com", "log": "warn"},
+		"staging":     {"host": "staging.example.com", "log": "warn", "timeout": "30"},
+		"development": {"host": "staging.example.com", "log": "debug", "timeout": "30"}}
+	assertConfigMapsEqual(conf, expected, t)
+	doc, err := ParseDocument(strings.NewReader(inheritanceDocument), inheritanceOptions)
+	assertErrorIsNil(err, t)
+	inheritance, err := doc.Inheritance()
+	assertErrorIsNil(err, t)
+	flat, err := doc.Config().Flatten(inheritance)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(flat, expected, t)
+	conf, err = ParseConfig(strings.NewReader(inheritanceDocument), nil)
+	assertErrorIsNil(err, t)
+	if !conf.HasSection("staging : production, defaults") {
+		t.Errorf("expected the header to be the section name without the option, got %v", conf)
+	}
+}
+
+func TestInheritanceErrors(t *testing.T) {
+	input := "[a : b]\n[b : c]\n[c : a]\n"
+	_, err := ParseConfig(strings.NewReader(input), inheritanceOptions)
+	if cycle, ok := err.(InheritanceCycleError); !ok || len(cycle.Sections) != 4 {
+		t.Errorf("expected InheritanceCycleError, got %v", err)
+	}
+	doc, err := ParseDocument(strings.NewReader("[a : a]\n"), inheritanceOptions)
+	assertErrorIsNil(err, t)
+	_, err = doc.Inheritance()
+	if cycle, ok := err.(InheritanceCycleError); !ok || !reflect.DeepEqual(cycle.Sections, []string{"a", "a"}) {
+		t.Errorf("expected InheritanceCycleError, got %v", err)
+	}
+	_, err = ParseConfig(strings.NewReader("[a : missing]\n"), inheritanceOptions)
+	if err != (MissingParentError{"a", "missing"}) {
+		t.Errorf("expected MissingParentError, got %v", err)
+	}
+	_, err = ParseDocument(strings.NewReader("[a]\n[b :]\n"), inheritanceOptions)
+	if err != (ParseError{2, EmptyParentError}) {
+		t.Errorf("expected a ParseError in line 2, got %v", err)
+	}
+}
+
+func TestInheritanceCaseFolding(t *testing.T) {
+	options := &ParseOptions{Inheritance: true, CaseFolding: FoldASCII}
+	cases := []struct {
+		input, origin string
+	}{
+		{"[Base]\nx = 1\n[child : base]\ny = 2\n", "Base"},
+		{"[child : base]\ny = 2\n[Base]\nx = 1\n", "base"}}
+	for _, c := range cases {
+		conf, err := ParseConfig(strings.NewReader(c.input), options)
+		assertErrorIsNil(err, t)
+		doc, err := ParseDocument(strings.NewReader(c.input), options)
+		assertErrorIsNil(err, t)
+		inheritance, err := doc.Inheritance()
+		assertErrorIsNil(err, t)
+		value, origin, err := doc.Config().Lookup("child", "x", inheritance)
+		assertErrorIsNil(err, t)
+		if value != "1" || origin != c.origin {
+			t.Errorf("expected 1 from %s, got %q from %q", c.origin, value, origin)
+		}
+		flat, err := doc.Config().Flatten(inheritance)
+		assertErrorIsNil(err, t)
+		assertConfigMapsEqual(flat, conf, t)
+	}
+}
@@ -0,0 +1,224 @@
+// Package lint reports constructs in ini files which are syntactically valid,
+// but most likely not what the author meant, e.g. properties which are
+// assigned twice or values which look like they end with a comment.
+//
+// Each warning belongs to a rule. Rules can be disabled for a whole run with
+// Options.Disabled, or within the file with comments of the form
+//
+//	# inilint:disable rule-id[,rule-id...]
+//	# inilint:disable-file rule-id[,rule-id...]
+//
+// The first form suppresses the rules for the next section or assignment
+// only, the second one for the whole file. The rule id "all" matches every
+// rule.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/derdon/ini"
+)
+
+// A Rule is a check performed by Lint.
+type Rule struct {
+	ID          string
+	Description string
+}
+
+// All rules known to Lint, sorted by their ID.
+var Rules = []Rule{
+	{"duplicate-key", "a property is assigned more than once in a section; only the last value is used"},
+	{"duplicate-section", "a section is declared more than once; its properties are merged"},
+	{"empty-value", "a property is assigned an empty value"},
+	{"inline-comment", "a value contains # or ; after whitespace, which is not a comment but part of the value"},
+	{"mixed-comment-markers", "comments are introduced with both # and ;"},
+	{"mixed-delimiters", "assignments are written both with and without spaces around the equal sign"},
+	{"trailing-whitespace", "a line ends with whitespace"},
+}
+
+// A Warning is a problem found by Lint. Line and Column start at 1; the
+// column is counted in bytes.
+type Warning struct {
+	Line    int
+	Column  int
+	Rule    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", w.Line, w.Column, w.Message, w.Rule)
+}
+
+// Options control which rules are checked by Lint.
+type Options struct {
+	// the IDs of the rules which are not checked
+	Disabled []string
+}
+
+var (
+	directivePattern     = regexp.MustCompile(`^[#;]\s*inilint:(disable|disable-file)\s+(\S+)`)
+	inlineCommentPattern = regexp.MustCompile(`\s[#;]`)
+)
+
+type linter struct {
+	lines    []string
+	warnings []Warning
+	// rules disabled for the whole file
+	disabled map[string]bool
+	// rules disabled for single lines
+	disabledLines map[int]map[string]bool
+}
+
+// Check the ini file s and return the warnings sorted by their position. If
+// the file cannot be parsed, the ini.ParseError is returned.
+func Lint(s string, options *Options) ([]Warning, error) {
+	doc, err := ini.NewDocumentFromString(s)
+	if err != nil {
+		return nil, err
+	}
+	l := &linter{
+		lines:         strings.Split(strings.TrimSuffix(s, "\n"), "\n"),
+		warnings:      []Warning{},
+		disabled:      make(map[string]bool),
+		disabledLines: make(map[int]map[string]bool)}
+	if options != nil {
+		for _, rule := range options.Disabled {
+			l.disabled[rule] = true
+		}
+	}
+	l.readDirectives()
+	l.checkLines()
+	l.checkDocument(doc)
+	sort.SliceStable(l.warnings, func(i, j int) bool {
+		if l.warnings[i].Line != l.warnings[j].Line {
+			return l.warnings[i].Line < l.warnings[j].Line
+		}
+		return l.warnings[i].Column < l.warnings[j].Column
+	})
+	return l.warnings, nil
+}
+
+func isComment(trimmedLine string) bool {
+	return strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, ";")
+}
+
+// find the inilint comments and remember which rules they disable
+func (l *linter) readDirectives() {
+	pending := make(map[string]bool)
+	for i, line := range l.lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+		if !isComment(trimmedLine) {
+			if len(pending) > 0 {
+				l.disabledLines[i+1] = pending
+				pending = make(map[string]bool)
+			}
+			continue
+		}
+		matches := directivePattern.FindStringSubmatch(trimmedLine)
+		if matches == nil {
+			continue
+		}
+		for _, rule := range strings.Split(matches[2], ",") {
+			if matches[1] == "disable-file" {
+				l.disabled[rule] = true
+			} else {
+				pending[rule] = true
+			}
+		}
+	}
+}
+
+func (l *linter) warn(line, column int, rule, format string, args ...interface{}) {
+	if l.disabled[rule] || l.disabled["all"] || l.disabledLines[line][rule] || l.disabledLines[line]["all"] {
+		return
+	}
+	l.warnings = append(l.warnings, Warning{line, column, rule, fmt.Sprintf(format, args...)})
+}
+
+// run the rules which only need the text of the lines
+func (l *linter) checkLines() {
+	var firstMarker byte
+	var firstSpaced, spaced bool
+	firstAssignment := true
+	for i, line := range l.lines {
+		lineNumber := i + 1
+		if trimmed := strings.TrimRight(line, " \t\r"); len(trimmed) < len(strings.TrimRight(line, "\r")) {
+			l.warn(lineNumber, len(trimmed)+1, "trailing-whitespace", "trailing whitespace")
+		}
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "[") {
+			continue
+		}
+		if isComment(trimmedLine) {
+			if firstMarker == 0 {
+				firstMarker = trimmedLine[0]
+			} else if trimmedLine[0] != firstMarker {
+				column := strings.IndexByte(line, trimmedLine[0]) + 1
+				l.warn(lineNumber, column, "mixed-comment-markers",
+					"comment starts with %c, but the first comment of the file starts with %c",
+					trimmedLine[0], firstMarker)
+			}
+			continue
+		}
+		equalSign := strings.IndexByte(line, '=')
+		if equalSign < 0 {
+			continue
+		}
+		spaced = equalSign > 0 && (line[equalSign-1] == ' ' || line[equalSign-1] == '\t')
+		if firstAssignment {
+			firstSpaced = spaced
+			firstAssignment = false
+		} else if spaced != firstSpaced {
+			l.warn(lineNumber, equalSign+1, "mixed-delimiters",
+				"equal sign is %s, unlike in the first assignment of the file",
+				map[bool]string{true: "preceded by whitespace", false: "not preceded by whitespace"}[spaced])
+		}
+		if loc := inlineCommentPattern.FindStringIndex(line[equalSign+1:]); loc != nil {
+			column := equalSign + 1 + loc[0] + 2
+			l.warn(lineNumber, column, "inline-comment",
+				"%c is not a comment here, but part of the value", line[column-1])
+		}
+	}
+}
+
+// return the column of the first non-whitespace character of the line
+func (l *linter) column(line int) int {
+	text := l.lines[line-1]
+	return len(text) - len(strings.TrimLeft(text, " \t")) + 1
+}
+
+// run the rules which need to know the structure of the file
+func (l *linter) checkDocument(doc *ini.Document) {
+	sectionLines := make(map[string]int)
+	propertyLines := make(map[string]map[string]int)
+	for _, section := range doc.Sections {
+		if first, exists := sectionLines[section.Name]; exists {
+			l.warn(section.Line, l.column(section.Line), "duplicate-section",
+				"section %q was already declared in line %d", section.Name, first)
+		} else {
+			sectionLines[section.Name] = section.Line
+			propertyLines[section.Name] = make(map[string]int)
+		}
+		for _, property := range section.Properties {
+			// properties of the form key[] = value are meant to be
+			// assigned several times
+			isArray := strings.HasSuffix(property.Name, "[]")
+			if first, exists := propertyLines[section.Name][property.Name]; exists && !isArray {
+				l.warn(property.Line, l.column(property.Line), "duplicate-key",
+					"property %q was already assigned in line %d", property.Name, first)
+			} else {
+				propertyLines[section.Name][property.Name] = property.Line
+			}
+			if property.Value == "" {
+				l.warn(property.Line, l.column(property.Line), "empty-value",
+					"property %q has an empty value", property.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/derdon/ini"
+)
+
+func expectWarnings(t *testing.T, input string, options *Options, expected []Warning) {
+	t.Helper()
+	warnings, err := Lint(input, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected\n%v\ngot\n%v", expected, warnings)
+	}
+}
+
+func TestLintCleanFile(t *testing.T) {
+	expectWarnings(t, "# comment\n[a]\nx = 1\n\n[b]\ny = 2\n", nil, []Warning{})
+}
+
+func TestLintDuplicates(t *testing.T) {
+	input := "[a]\nx = 1\n  x = 2\n[b]\n[a]\nx = 3\ny = 4\n"
+	expectWarnings(t, input, nil, []Warning{
+		{3, 3, "duplicate-key", `property "x" was already assigned in line 2`},
+		{5, 1, "duplicate-section", `section "a" was already declared in line 1`},
+		{6, 1, "duplicate-key", `property "x" was already assigned in line 2`}})
+}
+
+func TestLintValues(t *testing.T) {
+	input := "[a]\nx = 1 # one\ny =\nz = a#b\n"
+	expectWarnings(t, input, nil, []Warning{
+		{2, 7, "inline-comment", "# is not a comment here, but part of the value"},
+		{3, 1, "empty-value", `property "y" has an empty value`}})
+}
+
+func TestLintLineRules(t *testing.T) {
+	input := "# one\n[a] \nx = 1\ny=2\t\n; two\n"
+	expectWarnings(t, input, nil, []Warning{
+		{2, 4, "trailing-whitespace", "trailing whitespace"},
+		{4, 2, "mixed-delimiters", "equal sign is not preceded by whitespace, unlike in the first assignment of the file"},
+		{4, 4, "trailing-whitespace", "trailing whitespace"},
+		{5, 1, "mixed-comment-markers", "comment starts with ;, but the first comment of the file starts with #"}})
+}
+
+func TestLintDisabledRules(t *testing.T) {
+	input := "[a]\nx =\n# inilint:disable duplicate-key\nx = 1\nx = 2\n"
+	expectWarnings(t, input, &Options{Disabled: []string{"empty-value"}}, []Warning{
+		{5, 1, "duplicate-key", `property "x" was already assigned in line 2`}})
+	input = "; inilint:disable-file duplicate-key,empty-value\n[a]\nx =\nx = 1\n"
+	expectWarnings(t, input, nil, []Warning{})
+	input = "[a]\n; inilint:disable all\nx = 1 ; one  \n"
+	expectWarnings(t, input, nil, []Warning{})
+}
+
+func TestLintSyntaxError(t *testing.T) {
+	_, err := Lint("[a]\nbroken\n", nil)
+	if err != (ini.ParseError{Line: 2, Err: ini.MissingEqualSignError}) {
+		t.Errorf("expected a ParseError, got %v", err)
+	}
+}
+
+func TestRulesAreSorted(t *testing.T) {
+	for i := 1; i < len(Rules); i++ {
+		if Rules[i-1].ID >= Rules[i].ID {
+			t.Errorf("rule %q is not sorted", Rules[i].ID)
+		}
+	}
+}
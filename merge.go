@@ -0,0 +1,116 @@
+package ini
+
+import "fmt"
+
+// A MergeConflict describes a property which was changed differently in both
+// configs passed to Merge3. The In* fields tell whether the property exists in
+// the respective config at all; a missing property means that it (or its
+// whole section) was removed.
+type MergeConflict struct {
+	Section  string
+	Property string
+	Base     string
+	Ours     string
+	Theirs   string
+	InBase   bool
+	InOurs   bool
+	InTheirs bool
+}
+
+func (conflict MergeConflict) String() string {
+	describe := func(value string, exists bool) string {
+		if !exists {
+			return "<removed>"
+		}
+		return fmt.Sprintf("%q", value)
+	}
+	return fmt.Sprintf("[%s] %s: base %s, ours %s, theirs %s",
+		conflict.Section, conflict.Property,
+		describe(conflict.Base, conflict.InBase),
+		describe(conflict.Ours, conflict.InOurs),
+		describe(conflict.Theirs, conflict.InTheirs))
+}
+
+// a value of a property together with the information whether the property
+// exists at all
+type mergeValue struct {
+	value  string
+	exists bool
+}
+
+func lookupMergeValue(c *Config, section, property string) mergeValue {
+	value, exists := (*c)[section][property]
+	return mergeValue{value, exists}
+}
+
+// Merge the changes which were made to base in ours and in theirs into a new
+// config. None of the passed configs are modified.
+//
+// Each property is merged on its own: if only one side changed (or added or
+// removed) a property, this change is taken. If both sides made the same
+// change, it is taken as well. If both sides changed a property differently,
+// the value of ours is kept and the property is reported as a MergeConflict.
+// Sections are added and removed the same way, but a section which was
+// removed by one side is kept if the other side changed any of its
+// properties. Such changes are reported as conflicts, but the changed values
+// are kept, even if ours removed the section. The conflicts are sorted by
+// section and property name.
+func Merge3(base, ours, theirs *Config) (*Config, []MergeConflict) {
+	merged := make(Config)
+	conflicts := []MergeConflict{}
+	for _, section := range unionOfSections(base, ours, theirs) {
+		inBase, inOurs, inTheirs := base.HasSection(section), ours.HasSection(section), theirs.HasSection(section)
+		properties := make(map[string]string)
+		for _, property := range unionOfProperties(section, base, ours, theirs) {
+			baseValue := lookupMergeValue(base, section, property)
+			ourValue := lookupMergeValue(ours, section, property)
+			theirValue := lookupMergeValue(theirs, section, property)
+			result := ourValue
+			switch {
+			case ourValue == theirValue, theirValue == baseValue:
+			case ourValue == baseValue:
+				result = theirValue
+			default:
+				conflicts = append(conflicts, MergeConflict{
+					section, property,
+					baseValue.value, ourValue.value, theirValue.value,
+					baseValue.exists, ourValue.exists, theirValue.exists})
+				// keep the section with the changes of theirs
+				if !inOurs {
+					result = theirValue
+				}
+			}
+			if result.exists {
+				properties[property] = result.value
+			}
+		}
+		keep := inOurs
+		if inOurs == inBase {
+			keep = inTheirs
+		}
+		if keep || len(properties) > 0 {
+			merged[section] = properties
+		}
+	}
+	return &merged, conflicts
+}
+
+func unionOfSections(configs ...*Config) []string {
+	seen := make(map[string]string)
+	for _, c := range configs {
+		for section := range *c {
+			seen[section] = section
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func unionOfProperties(section string, configs ...*Config) []string {
+	seen := make(map[string]string)
+	for _, c := range configs {
+		for property := range (*c)[section] {
+			seen[property] = property
+		}
+	}
+	return sortedKeys(seen)
+}
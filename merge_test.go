@@ -0,0 +1,93 @@
+package ini
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge3NonConflictingChanges(t *testing.T) {
+	base := &Config{
+		"server":  {"host": "localhost", "port": "80", "debug": "false"},
+		"old":     {"a": "b"},
+		"cleanup": {"x": "y"}}
+	ours := &Config{
+		"server":  {"host": "example.org", "port": "80", "debug": "false"},
+		"old":     {"a": "b"},
+		"cleanup": {"x": "y"},
+		"mine":    make(map[string]string)}
+	theirs := &Config{
+		"server":  {"host": "localhost", "port": "8080", "timeout": "30"},
+		"cleanup": {"x": "y"}}
+	merged, conflicts := Merge3(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", conflicts)
+	}
+	expected := &Config{
+		"server":  {"host": "example.org", "port": "8080", "timeout": "30"},
+		"cleanup": {"x": "y"},
+		"mine":    make(map[string]string)}
+	assertConfigMapsEqual(merged, expected, t)
+}
+
+func TestMerge3SameChangeOnBothSides(t *testing.T) {
+	base := &Config{"s": {"a": "1"}}
+	ours := &Config{"s": {"a": "2"}}
+	theirs := &Config{"s": {"a": "2"}}
+	merged, conflicts := Merge3(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", conflicts)
+	}
+	assertConfigMapsEqual(merged, &Config{"s": {"a": "2"}}, t)
+}
+
+func TestMerge3Conflicts(t *testing.T) {
+	base := &Config{"s": {"a": "1", "b": "1"}}
+	ours := &Config{"s": {"a": "2", "b": "1", "c": "ours"}}
+	theirs := &Config{"s": {"a": "3", "c": "theirs"}}
+	merged, conflicts := Merge3(base, ours, theirs)
+	expectedConflicts := []MergeConflict{
+		{"s", "a", "1", "2", "3", true, true, true},
+		{"s", "c", "", "ours", "theirs", false, true, true}}
+	if !reflect.DeepEqual(conflicts, expectedConflicts) {
+		t.Errorf("expected %v, got %v", expectedConflicts, conflicts)
+	}
+	assertConfigMapsEqual(merged, &Config{"s": {"a": "2", "c": "ours"}}, t)
+}
+
+func TestMerge3RemovedSectionWithChangedProperty(t *testing.T) {
+	base := &Config{"s": {"a": "1", "b": "1"}}
+	ours := &Config{"s": {"a": "2", "b": "1"}}
+	theirs := make(Config)
+	merged, conflicts := Merge3(base, ours, &theirs)
+	expectedConflicts := []MergeConflict{{"s", "a", "1", "2", "", true, true, false}}
+	if !reflect.DeepEqual(conflicts, expectedConflicts) {
+		t.Errorf("expected %v, got %v", expectedConflicts, conflicts)
+	}
+	assertConfigMapsEqual(merged, &Config{"s": {"a": "2"}}, t)
+	// the same with the section removed by ours
+	merged, conflicts = Merge3(base, &theirs, ours)
+	expectedConflicts = []MergeConflict{{"s", "a", "1", "", "2", true, false, true}}
+	if !reflect.DeepEqual(conflicts, expectedConflicts) {
+		t.Errorf("expected %v, got %v", expectedConflicts, conflicts)
+	}
+	assertConfigMapsEqual(merged, &Config{"s": {"a": "2"}}, t)
+}
+
+func TestMerge3DoesNotModifyInputs(t *testing.T) {
+	base := &Config{"s": {"a": "1"}}
+	ours := &Config{"s": {"a": "1"}}
+	theirs := &Config{"s": {"a": "2"}}
+	merged, _ := Merge3(base, ours, theirs)
+	merged.Set("s", "a", "changed")
+	assertConfigMapsEqual(base, &Config{"s": {"a": "1"}}, t)
+	assertConfigMapsEqual(ours, &Config{"s": {"a": "1"}}, t)
+	assertConfigMapsEqual(theirs, &Config{"s": {"a": "2"}}, t)
+}
+
+func TestMergeConflictString(t *testing.T) {
+	conflict := MergeConflict{"s", "a", "1", "2", "", true, true, false}
+	expected := `[s] a: base "1", ours "2", theirs <removed>`
+	if s := conflict.String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
@@ -0,0 +1,141 @@
+package ini
+
+import "strings"
+
+// the position of an assignment within a document
+type propertyPosition struct {
+	section *DocumentSection
+	index   int
+}
+
+// return the positions of all assignments of the given property
+func (d *Document) propertyPositions(section, property string) (positions []propertyPosition, err error) {
+	sections := d.sections(section)
+	if len(sections) == 0 {
+		return nil, NoSectionError
+	}
+	for _, s := range sections {
+		for i, p := range s.Properties {
+			if d.parseOptions().CaseFolding.Equal(p.Name, property) {
+				positions = append(positions, propertyPosition{s, i})
+			}
+		}
+	}
+	return positions, nil
+}
+
+// Get all values of the passed property in the given section in the order of
+// their assignment. If the document was parsed with ParseOptions.Operators,
+// the operators are taken into account. If the section does not exist,
+// NoSectionError is returned. If the property does not exist in the given
+// section, NoPropertyError is returned.
+func (d *Document) GetAll(section, property string) (values []string, err error) {
+	return d.resolve(section, property)
+}
+
+// Add another value to the given property of the passed section. The new
+// assignment is inserted after the last assignment of the property, or
+// appended to the last declaration of the section if the property does not
+// exist yet. If the existing assignments use the form key[] = value, the new
+// one does as well. If the document was parsed with ParseOptions.Operators,
+// the new assignment uses +=. Adding values to non-existing sections will
+// return NoSectionError.
+func (d *Document) Add(section, property, value string) error {
+	positions, err := d.propertyPositions(section, property)
+	if err != nil {
+		return err
+	}
+	added := &DocumentProperty{Name: property, Value: value}
+	if len(positions) == 0 {
+		sections := d.sections(section)
+		last := sections[len(sections)-1]
+		last.Properties = append(last.Properties, added)
+		return nil
+	}
+	pos := positions[len(positions)-1]
+	added.ArraySyntax = pos.section.Properties[pos.index].ArraySyntax
+	if d.parseOptions().Operators {
+		added.Operator = OperatorAppend
+	}
+	pos.section.insertProperty(pos.index+1, added)
+	return nil
+}
+
+// Replace all values of the given property in the passed section. The new
+// assignments take the place of the first existing one, all others are
+// removed together with their comments. If the property does not exist yet,
+// the assignments are appended to the last declaration of the section. An
+// empty list of values removes the property. Setting values in non-existing
+// sections will return NoSectionError.
+func (d *Document) SetAll(section, property string, values []string) error {
+	positions, err := d.propertyPositions(section, property)
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		for _, value := range values {
+			d.Add(section, property, value)
+		}
+		return nil
+	}
+	first := positions[0].section.Properties[positions[0].index]
+	d.RemoveProperty(section, property)
+	if len(values) == 0 {
+		return nil
+	}
+	first.assign(values[0])
+	s, index := positions[0].section, positions[0].index
+	s.insertProperty(index, first)
+	for i, value := range values[1:] {
+		added := &DocumentProperty{Name: property, Value: value, ArraySyntax: first.ArraySyntax}
+		if d.parseOptions().Operators {
+			added.Operator = OperatorAppend
+		}
+		s.insertProperty(index+i+1, added)
+	}
+	return nil
+}
+
+// Remove all assignments of the given value to the property in the passed
+// section. If no such section exists, NoSectionError will be returned. If the
+// property does not exist, NoPropertyError will be returned, and if it never
+// has the given value, NoValueError.
+func (d *Document) RemoveValue(section, property, value string) error {
+	positions, err := d.propertyPositions(section, property)
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		return NoPropertyError{property}
+	}
+	found := false
+	for _, s := range d.sections(section) {
+		properties := []*DocumentProperty{}
+		for _, p := range s.Properties {
+			if d.parseOptions().CaseFolding.Equal(p.Name, property) && p.Value == value {
+				found = true
+				continue
+			}
+			properties = append(properties, p)
+		}
+		s.Properties = properties
+	}
+	if !found {
+		return NoValueError{property, value}
+	}
+	return nil
+}
+
+func (s *DocumentSection) insertProperty(index int, property *DocumentProperty) {
+	s.Properties = append(s.Properties, nil)
+	copy(s.Properties[index+1:], s.Properties[index:])
+	s.Properties[index] = property
+}
+
+// If the property is written as key[], return key and true.
+func splitArrayKey(property string) (string, bool) {
+	if strings.HasSuffix(property, "[]") && len(property) > 2 {
+		return strings.TrimSpace(strings.TrimSuffix(property, "[]")), true
+	}
+	return property, false
+}
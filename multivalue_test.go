@@ -0,0 +1,100 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const multiValueDocument = `[Service]
+ExecStartPre = /bin/mkdir -p /run/app
+Environment = DEBUG
+# second value
+ExecStartPre = /bin/chown app /run/app
+`
+
+func expectValues(expected []string, values []string, t *testing.T) {
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %#v, got %#v", expected, values)
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	doc, _ := NewDocumentFromString(multiValueDocument)
+	values, err := doc.GetAll("Service", "ExecStartPre")
+	assertErrorIsNil(err, t)
+	expectValues([]string{"/bin/mkdir -p /run/app", "/bin/chown app /run/app"}, values, t)
+	if _, err := doc.GetAll("Service", "missing"); err != (NoPropertyError{"missing"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+	if _, err := doc.GetAll("missing", "ExecStartPre"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	doc, _ := NewDocumentFromString(multiValueDocument)
+	assertErrorIsNil(doc.Add("Service", "Environment", "VERBOSE"), t)
+	assertErrorIsNil(doc.Add("Service", "ExecStart", "/bin/app"), t)
+	expected := `[Service]
+ExecStartPre = /bin/mkdir -p /run/app
+Environment = DEBUG
+Environment = VERBOSE
+# second value
+ExecStartPre = /bin/chown app /run/app
+ExecStart = /bin/app
+`
+	expectDocumentString(expected, doc, t)
+	if err := doc.Add("missing", "a", "b"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
+
+func TestSetAll(t *testing.T) {
+	doc, _ := NewDocumentFromString(multiValueDocument)
+	assertErrorIsNil(doc.SetAll("Service", "ExecStartPre", []string{"a", "b", "c"}), t)
+	expected := `[Service]
+ExecStartPre = a
+ExecStartPre = b
+ExecStartPre = c
+Environment = DEBUG
+`
+	expectDocumentString(expected, doc, t)
+	assertErrorIsNil(doc.SetAll("Service", "ExecStartPre", nil), t)
+	assertErrorIsNil(doc.SetAll("Service", "New", []string{"x", "y"}), t)
+	expectDocumentString("[Service]\nEnvironment = DEBUG\nNew = x\nNew = y\n", doc, t)
+}
+
+func TestRemoveValue(t *testing.T) {
+	doc, _ := NewDocumentFromString(multiValueDocument)
+	assertErrorIsNil(doc.RemoveValue("Service", "ExecStartPre", "/bin/mkdir -p /run/app"), t)
+	values, _ := doc.GetAll("Service", "ExecStartPre")
+	expectValues([]string{"/bin/chown app /run/app"}, values, t)
+	err := doc.RemoveValue("Service", "ExecStartPre", "nope")
+	if err != (NoValueError{"ExecStartPre", "nope"}) {
+		t.Errorf("expected NoValueError, got %v", err)
+	}
+}
+
+func TestParseArrayKeys(t *testing.T) {
+	input := "[php]\nextension[] = curl\nextension[]=gd\n"
+	options := &ParseOptions{ArrayKeys: true, DuplicateProperties: DuplicateError}
+	doc, err := ParseDocument(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	values, err := doc.GetAll("php", "extension")
+	assertErrorIsNil(err, t)
+	expectValues([]string{"curl", "gd"}, values, t)
+	assertErrorIsNil(doc.Add("php", "extension", "mbstring"), t)
+	expectDocumentString(input+"extension[] = mbstring\n", doc, t)
+	conf, err := ParseConfig(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"php": {"extension": "gd"}}, t)
+}
+
+func TestArrayKeysDisabledByDefault(t *testing.T) {
+	doc, err := NewDocumentFromString("[php]\nextension[] = curl\n")
+	assertErrorIsNil(err, t)
+	if !doc.HasProperty("php", "extension[]") {
+		t.Error("expected the property to be called extension[]")
+	}
+}
@@ -0,0 +1,103 @@
+package ini
+
+// An AssignmentOperator tells how an assignment of a Document changes the
+// value of its property. Operators other than OperatorAssign are only
+// recognized if ParseOptions.Operators is set.
+type AssignmentOperator int
+
+const (
+	// key = value
+	OperatorAssign AssignmentOperator = iota
+	// key += value appends the value to the values assigned before
+	OperatorAppend
+	// !unset key removes all values assigned before
+	OperatorUnset
+)
+
+const unsetKeyword = "!unset"
+
+// the separator used by Config to join appended values if
+// ParseOptions.ListSeparator is empty
+const defaultListSeparator = ", "
+
+func listSeparator(options *ParseOptions) string {
+	if options.ListSeparator == "" {
+		return defaultListSeparator
+	}
+	return options.ListSeparator
+}
+
+// Change the config like the assignment with the given operator does. Appended
+// values are joined with the list separator of the options.
+func (c *Config) applyOperator(section string, item *Item, op AssignmentOperator, options *ParseOptions) {
+	switch op {
+	case OperatorAssign:
+		c.Set(section, item.Property, item.Value)
+	case OperatorAppend:
+		if value, err := c.Get(section, item.Property); err == nil {
+			c.Set(section, item.Property, value+listSeparator(options)+item.Value)
+		} else {
+			c.Set(section, item.Property, item.Value)
+		}
+	case OperatorUnset:
+		c.RemoveProperty(section, item.Property)
+	}
+}
+
+// Return the values of the property after applying all of its assignments in
+// order. Without ParseOptions.Operators, every assignment adds a value, see
+// GetAll. With operators, = replaces all values assigned before, += appends
+// a value and !unset removes all values.
+func (d *Document) resolve(section, property string) (values []string, err error) {
+	values = []string{}
+	positions, err := d.propertyPositions(section, property)
+	if err != nil {
+		return values, err
+	}
+	if len(positions) == 0 {
+		return values, NoPropertyError{property}
+	}
+	var last *DocumentProperty
+	for _, pos := range positions {
+		last = pos.section.Properties[pos.index]
+		switch {
+		case last.Operator == OperatorUnset:
+			values = []string{}
+		case last.Operator == OperatorAssign && d.parseOptions().Operators:
+			values = []string{last.Value}
+		default:
+			values = append(values, last.Value)
+		}
+	}
+	if last.Operator == OperatorUnset {
+		return values, NoPropertyError{property}
+	}
+	return values, nil
+}
+
+// Apply the overlay to the document, e.g. a local configuration file to the
+// defaults shipped with an application. The sections and assignments of the
+// overlay are applied in order:
+//
+//	key = value    replaces all values of the property in the document
+//	key += value   adds a value to the values of the document, see Add
+//	!unset key     removes the property from the document
+//
+// Sections which do not exist in the document yet are appended to it.
+func (d *Document) Overlay(overlay *Document) {
+	for _, section := range overlay.Sections {
+		if !d.HasSection(section.Name) {
+			d.AddSection(section.Name)
+		}
+		for _, property := range section.Properties {
+			switch property.Operator {
+			case OperatorAssign:
+				d.SetAll(section.Name, property.Name, []string{property.Value})
+			case OperatorAppend:
+				d.Add(section.Name, property.Name, property.Value)
+			case OperatorUnset:
+				d.RemoveProperty(section.Name, property.Name)
+			}
+		}
+	}
+}
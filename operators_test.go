@@ -0,0 +1,107 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+var operatorOptions = &ParseOptions{Operators: true}
+
+const operatorDocument = `[app]
+plugins = a
+plugins += b
+plugins += c
+path = /usr
+!unset path
+flags += x
+`
+
+func TestParseAssignmentOperators(t *testing.T) {
+	cases := []struct {
+		line     string
+		property string
+		value    string
+		op       AssignmentOperator
+	}{
+		{"a = b", "a", "b", OperatorAssign},
+		{"a += b", "a", "b", OperatorAppend},
+		{"a+=b", "a", "b", OperatorAppend},
+		{"  !unset  a ", "a", "", OperatorUnset}}
+	for _, c := range cases {
+		a, err := parseAssignment(c.line, operatorOptions)
+		assertErrorIsNil(err, t)
+		expectProperty(c.property, a.Property, t)
+		expectValue(c.value, a.Value, t)
+		if a.op != c.op {
+			t.Errorf("%q: expected operator %d, got %d", c.line, c.op, a.op)
+		}
+	}
+	for _, line := range []string{"!unset", "!unsetfoo"} {
+		if _, err := parseAssignment(line, operatorOptions); err != MissingPropertyError {
+			t.Errorf("%q: expected MissingPropertyError, got %v", line, err)
+		}
+	}
+	a, err := parseAssignment("a += b", defaultParseOptions)
+	assertErrorIsNil(err, t)
+	expectProperty("a +", a.Property, t)
+}
+
+func TestParseConfigOperators(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(operatorDocument), operatorOptions)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"app": {"plugins": "a, b, c", "flags": "x"}}, t)
+	options := &ParseOptions{Operators: true, ListSeparator: ":", DuplicateProperties: DuplicateError}
+	conf, err = ParseConfig(strings.NewReader(operatorDocument), options)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"app": {"plugins": "a:b:c", "flags": "x"}}, t)
+}
+
+func TestDocumentOperators(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(operatorDocument), operatorOptions)
+	assertErrorIsNil(err, t)
+	expectDocumentString(operatorDocument, doc, t)
+	values, err := doc.GetAll("app", "plugins")
+	assertErrorIsNil(err, t)
+	expectValues([]string{"a", "b", "c"}, values, t)
+	if doc.HasProperty("app", "path") {
+		t.Error("path should have been unset")
+	}
+	items, _ := doc.GetItems("app")
+	if len(items) != 2 {
+		t.Errorf("expected the items plugins and flags, got %v", items)
+	}
+	assertConfigMapsEqual(doc.Config(), &Config{"app": {"plugins": "a, b, c", "flags": "x"}}, t)
+}
+
+func TestDocumentOperatorsAddAndSet(t *testing.T) {
+	doc, _ := ParseDocument(strings.NewReader("[app]\nplugins = a\n"), operatorOptions)
+	assertErrorIsNil(doc.Add("app", "plugins", "b"), t)
+	expectDocumentString("[app]\nplugins = a\nplugins += b\n", doc, t)
+	assertErrorIsNil(doc.Set("app", "plugins", "c"), t)
+	expectDocumentString("[app]\nplugins = a\nplugins = c\n", doc, t)
+	values, _ := doc.GetAll("app", "plugins")
+	expectValues([]string{"c"}, values, t)
+}
+
+func TestDocumentOverlay(t *testing.T) {
+	base, _ := NewDocumentFromString("[app]\nplugins = a\nplugins = b\npath = /usr\nname = x\n")
+	overlay, err := ParseDocument(strings.NewReader(`[app]
+plugins += c
+!unset path
+name = y
+[extra]
+flag += on
+`), operatorOptions)
+	assertErrorIsNil(err, t)
+	base.Overlay(overlay)
+	expected := "[app]\nplugins = a\nplugins = b\nplugins = c\nname = y\n[extra]\nflag = on\n"
+	expectDocumentString(expected, base, t)
+	values, _ := base.GetAll("app", "plugins")
+	expectValues([]string{"a", "b", "c"}, values, t)
+}
+
+func TestFormatKeepsOperators(t *testing.T) {
+	doc, _ := ParseDocument(strings.NewReader("[app]\nx+=1\n  !unset   y\n"), operatorOptions)
+	doc.Format(&FormatOptions{AlignValues: true})
+	expectDocumentString("[app]\nx += 1\n!unset y\n", doc, t)
+}
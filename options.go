@@ -0,0 +1,148 @@
+package ini
+
+// A DuplicatePolicy decides what the parser does if a section is declared or
+// a property is assigned more than once.
+type DuplicatePolicy int
+
+const (
+	// Repeated sections are merged into one section. For properties, this
+	// is the same as DuplicateLastWins. This is the default.
+	DuplicateMerge DuplicatePolicy = iota
+	// Parsing fails with a DuplicateDefinitionError.
+	DuplicateError
+	// The first declaration is used, later ones are ignored.
+	DuplicateFirstWins
+	// The last declaration is used, earlier ones are ignored.
+	DuplicateLastWins
+	// All declarations are kept. Only a Document can represent this, so
+	// parsing a Config fails with UnsupportedPolicyError. Each declaration
+	// of a section is a record of its own, see Document.Records.
+	DuplicateKeepAll
+)
+
+// ParseOptions change how ParseConfig and ParseDocument read a file. The zero
+// value reads files the same way as NewConfigFromString.
+type ParseOptions struct {
+	DuplicateSections   DuplicatePolicy
+	DuplicateProperties DuplicatePolicy
+	// Read assignments of the form key[] = value as values of the property
+	// key. Such properties may be assigned several times regardless of
+	// DuplicateProperties. A Document keeps all values, see GetAll; a
+	// Config only keeps the last one.
+	ArrayKeys bool
+	// Recognize assignments of the form key += value, which append a value
+	// to the property, and !unset key, which removes the property. In a
+	// Document, = then replaces all values assigned before.
+	Operators bool
+	// The separator a Config puts between appended values. If it is empty,
+	// ", " is used.
+	ListSeparator string
+	// Accept lines consisting of a property name only, e.g. the flag
+	// skip-networking in my.cnf. A Config stores the empty string as their
+	// value, a Document remembers that they have no value, see IsFlag.
+	AllowNoValue bool
+	// How section and property names are compared. With a case folding,
+	// [Global] and [global] are the same section: a Config uses the first
+	// spelling of each name, a Document keeps every spelling but finds
+	// sections and properties regardless of their case. A Config is a
+	// plain map, so its HasSection, Get and Set compare names exactly; use
+	// HasSectionFold, GetFold and SetFold with the same case folding to
+	// look names up regardless of their case.
+	CaseFolding CaseFolding
+	// Read section headers like [remote "origin"] as a section and a quoted
+	// subsection, as git does. Within the quotes, \" and \\ stand for " and
+	// \. The section names are stored in the form returned by
+	// SubsectionName, see GetIn and SetIn.
+	Subsections bool
+	// Read section headers like [staging : production] as the section
+	// staging which inherits all properties of production. Several parents
+	// are separated by commas, e.g. [staging : production, defaults]. A
+	// Config receives the inherited properties, see Flatten, so it no
+	// longer tells which section defines a value; a Document keeps the
+	// parents in DocumentSection.Parents, see Document.Inheritance and
+	// Document.Config for using it with Lookup.
+	Inheritance bool
+	// Read section headers of the form [[name]] as another record of the
+	// section name, e.g. one of several backends. Such sections may be
+	// declared several times regardless of DuplicateSections, and the
+	// DuplicateProperties policy applies to each record separately. A
+	// Document keeps every record, see Records; a Config merges them.
+	SectionArrays bool
+	// The prefixes which begin a comment at the end of a line, e.g. "#" and
+	// ";". A prefix only counts if it is preceded by whitespace, is not
+	// within double quotes and not escaped with a backslash. If the list is
+	// empty, such comments are part of the value. A Document keeps the
+	// comments, see InlineComment.
+	InlineCommentPrefixes []string
+}
+
+var defaultParseOptions = &ParseOptions{}
+
+// A duplicateTracker remembers the lines in which sections and properties
+// were declared first and enforces the DuplicateError policy.
+type duplicateTracker struct {
+	options    *ParseOptions
+	sections   map[string]int
+	properties map[string]map[string]int
+}
+
+func newDuplicateTracker(options *ParseOptions) *duplicateTracker {
+	return &duplicateTracker{
+		options:    options,
+		sections:   make(map[string]int),
+		properties: make(map[string]map[string]int)}
+}
+
+// Record the declaration of a section and return whether it was declared
+// before. If the section replaces an earlier one or is kept as a record of
+// its own, its properties are forgotten.
+func (d *duplicateTracker) section(name string, line int) (repeated bool, err error) {
+	key := d.options.CaseFolding.key(name)
+	first, repeated := d.sections[key]
+	if !repeated {
+		d.sections[key] = line
+		d.properties[key] = make(map[string]int)
+		return false, nil
+	}
+	switch d.options.DuplicateSections {
+	case DuplicateError:
+		return true, DuplicateDefinitionError{name, "", first, line}
+	case DuplicateLastWins:
+		d.sections[key] = line
+		d.properties[key] = make(map[string]int)
+	case DuplicateKeepAll:
+		d.properties[key] = make(map[string]int)
+	}
+	return true, nil
+}
+
+// Record the declaration of another record of an array section, see
+// ParseOptions.SectionArrays. The properties of earlier records are
+// forgotten.
+func (d *duplicateTracker) record(name string, line int) {
+	key := d.options.CaseFolding.key(name)
+	if _, declared := d.sections[key]; !declared {
+		d.sections[key] = line
+	}
+	d.properties[key] = make(map[string]int)
+}
+
+// Record the assignment of a property and return whether it was assigned
+// before.
+func (d *duplicateTracker) property(section, name string, line int) (repeated bool, err error) {
+	properties := d.properties[d.options.CaseFolding.key(section)]
+	key := d.options.CaseFolding.key(name)
+	first, repeated := properties[key]
+	if !repeated {
+		properties[key] = line
+		return false, nil
+	}
+	switch d.options.DuplicateProperties {
+	case DuplicateError:
+		return true, DuplicateDefinitionError{section, name, first, line}
+	case DuplicateFirstWins:
+	default:
+		properties[key] = line
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+const duplicatesDocument = `[a]
+x = 1
+x = 2
+[b]
+y = 1
+[a]
+x = 3
+z = 1
+`
+
+func parseConfigWithPolicies(sections, properties DuplicatePolicy) (*Config, error) {
+	options := &ParseOptions{DuplicateSections: sections, DuplicateProperties: properties}
+	return ParseConfig(strings.NewReader(duplicatesDocument), options)
+}
+
+func parseDocumentWithPolicies(sections, properties DuplicatePolicy) (*Document, error) {
+	options := &ParseOptions{DuplicateSections: sections, DuplicateProperties: properties}
+	return ParseDocument(strings.NewReader(duplicatesDocument), options)
+}
+
+func TestParseConfigDefaultPolicies(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(duplicatesDocument), nil)
+	assertErrorIsNil(err, t)
+	expected, _ := NewConfigFromString(duplicatesDocument)
+	assertConfigMapsEqual(conf, expected, t)
+	assertConfigMapsEqual(conf, &Config{"a": {"x": "3", "z": "1"}, "b": {"y": "1"}}, t)
+}
+
+func TestParseConfigDuplicatePolicies(t *testing.T) {
+	cases := []struct {
+		sections, properties DuplicatePolicy
+		expected             *Config
+	}{
+		{DuplicateMerge, DuplicateFirstWins, &Config{"a": {"x": "1", "z": "1"}, "b": {"y": "1"}}},
+		{DuplicateFirstWins, DuplicateLastWins, &Config{"a": {"x": "2"}, "b": {"y": "1"}}},
+		{DuplicateLastWins, DuplicateError, &Config{"a": {"x": "3", "z": "1"}, "b": {"y": "1"}}},
+		{DuplicateFirstWins, DuplicateFirstWins, &Config{"a": {"x": "1"}, "b": {"y": "1"}}}}
+	for _, c := range cases {
+		conf, err := parseConfigWithPolicies(c.sections, c.properties)
+		if c.properties == DuplicateError {
+			// the first block of [a] contains a duplicate
+			if err != (DuplicateDefinitionError{"a", "x", 2, 3}) {
+				t.Errorf("expected DuplicateDefinitionError, got %v", err)
+			}
+			continue
+		}
+		assertErrorIsNil(err, t)
+		assertConfigMapsEqual(conf, c.expected, t)
+	}
+}
+
+func TestParseConfigDuplicateSectionError(t *testing.T) {
+	_, err := parseConfigWithPolicies(DuplicateError, DuplicateLastWins)
+	expected := DuplicateDefinitionError{"a", "", 1, 6}
+	if err != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+	if err.Error() != `section "a" declared in line 1 and again in line 6` {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestParseConfigKeepAllIsUnsupported(t *testing.T) {
+	_, err := parseConfigWithPolicies(DuplicateKeepAll, DuplicateMerge)
+	if err != UnsupportedPolicyError {
+		t.Errorf("expected UnsupportedPolicyError, got %v", err)
+	}
+}
+
+func TestParseDocumentKeepAll(t *testing.T) {
+	doc, err := parseDocumentWithPolicies(DuplicateKeepAll, DuplicateKeepAll)
+	assertErrorIsNil(err, t)
+	expectDocumentString(duplicatesDocument, doc, t)
+}
+
+func TestParseDocumentFirstWins(t *testing.T) {
+	doc, err := parseDocumentWithPolicies(DuplicateFirstWins, DuplicateFirstWins)
+	assertErrorIsNil(err, t)
+	expectDocumentString("[a]\nx = 1\n[b]\ny = 1\n", doc, t)
+}
+
+func TestParseDocumentLastWins(t *testing.T) {
+	doc, err := parseDocumentWithPolicies(DuplicateLastWins, DuplicateLastWins)
+	assertErrorIsNil(err, t)
+	expectDocumentString("[b]\ny = 1\n[a]\nx = 3\nz = 1\n", doc, t)
+	doc, err = parseDocumentWithPolicies(DuplicateMerge, DuplicateLastWins)
+	assertErrorIsNil(err, t)
+	expectDocumentString("[a]\n[b]\ny = 1\n[a]\nx = 3\nz = 1\n", doc, t)
+}
+
+func TestParseDocumentDuplicateError(t *testing.T) {
+	_, err := parseDocumentWithPolicies(DuplicateMerge, DuplicateError)
+	expected := DuplicateDefinitionError{"a", "x", 2, 3}
+	if err != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
@@ -0,0 +1,143 @@
+package ini
+
+import "sort"
+
+// return the indexes of the first and the last declaration of the section
+// in d.Sections, or -1 if it does not exist
+func (d *Document) sectionRange(section string) (first, last int) {
+	first, last = -1, -1
+	for i, s := range d.Sections {
+		if d.parseOptions().CaseFolding.Equal(s.Name, section) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	return first, last
+}
+
+func (d *Document) insertSection(index int, section *DocumentSection) {
+	d.Sections = append(d.Sections, nil)
+	copy(d.Sections[index+1:], d.Sections[index:])
+	d.Sections[index] = section
+}
+
+// Add a new section directly before the first declaration of the anchor and
+// its comments. If the anchor does not exist, NoSectionError is returned. If
+// the section already exists, DuplicateSectionError is returned.
+func (d *Document) InsertSectionBefore(section, anchor string) error {
+	first, _ := d.sectionRange(anchor)
+	if first < 0 {
+		return NoSectionError
+	}
+	if d.HasSection(section) {
+		return DuplicateSectionError
+	}
+	d.insertSection(first, &DocumentSection{Name: section, options: d.parseOptions()})
+	return nil
+}
+
+// Add a new section directly after the last declaration of the anchor. The
+// errors are the same as for InsertSectionBefore.
+func (d *Document) InsertSectionAfter(section, anchor string) error {
+	_, last := d.sectionRange(anchor)
+	if last < 0 {
+		return NoSectionError
+	}
+	if d.HasSection(section) {
+		return DuplicateSectionError
+	}
+	d.insertSection(last+1, &DocumentSection{Name: section, options: d.parseOptions()})
+	return nil
+}
+
+// Set the given property in the given section to the passed value. If the
+// property does not exist yet, it is inserted directly after the last
+// assignment of the anchor property; otherwise it keeps its position like
+// with Set. If the section does not exist, NoSectionError is returned. If the
+// anchor does not exist, NoPropertyError is returned.
+func (d *Document) SetAfter(section, anchor, property, value string) error {
+	positions, err := d.propertyPositions(section, anchor)
+	if err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		return NoPropertyError{anchor}
+	}
+	if p, err := d.lastProperty(section, property); err == nil {
+		p.assign(value)
+		return nil
+	}
+	pos := positions[len(positions)-1]
+	pos.section.insertProperty(pos.index+1, &DocumentProperty{Name: property, Value: value})
+	return nil
+}
+
+// Move all declarations of the given section together with their comments so
+// that the section is at the given position of the list returned by
+// GetSections, counting from 0. Repeated declarations become adjacent. If the
+// section does not exist, NoSectionError is returned. If the index is
+// negative or greater than the number of other sections,
+// IndexOutOfRangeError is returned.
+func (d *Document) MoveSection(section string, index int) error {
+	moved := d.sections(section)
+	if len(moved) == 0 {
+		return NoSectionError
+	}
+	rest := []*DocumentSection{}
+	for _, s := range d.Sections {
+		if !d.parseOptions().CaseFolding.Equal(s.Name, section) {
+			rest = append(rest, s)
+		}
+	}
+	others := (&Document{Sections: rest, options: d.parseOptions()}).GetSections()
+	if index < 0 || index > len(others) {
+		return IndexOutOfRangeError
+	}
+	d.Sections = rest
+	position := len(rest)
+	if index < len(others) {
+		position, _ = d.sectionRange(others[index])
+	}
+	for i, s := range moved {
+		d.insertSection(position+i, s)
+	}
+	return nil
+}
+
+// Sort the sections by their names with the given comparator, which reports
+// whether the name a comes before b. If less is nil, the names are sorted
+// alphabetically. The sort is stable, so repeated declarations keep their
+// order and become adjacent. The comments of a section move with it.
+func (d *Document) SortSections(less func(a, b string) bool) {
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	sort.SliceStable(d.Sections, func(i, j int) bool {
+		return less(d.Sections[i].Name, d.Sections[j].Name)
+	})
+}
+
+// Sort the properties within every declaration of the given section by their
+// names with the given comparator, which reports whether the name a comes
+// before b. If less is nil, the names are sorted alphabetically. The sort is
+// stable, so repeated assignments keep their order. The comments of a
+// property move with it. If the section does not exist, NoSectionError is
+// returned.
+func (d *Document) SortKeys(section string, less func(a, b string) bool) error {
+	sections := d.sections(section)
+	if len(sections) == 0 {
+		return NoSectionError
+	}
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	for _, s := range sections {
+		properties := s.Properties
+		sort.SliceStable(properties, func(i, j int) bool {
+			return less(properties[i].Name, properties[j].Name)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+const orderingDocument = `[b]
+y = 2
+x = 1
+[a]
+z = 3
+# about b
+[b]
+w = 0
+`
+
+func TestInsertSection(t *testing.T) {
+	doc, _ := NewDocumentFromString(orderingDocument)
+	assertErrorIsNil(doc.InsertSectionBefore("first", "b"), t)
+	assertErrorIsNil(doc.InsertSectionAfter("last", "b"), t)
+	assertErrorIsNil(doc.InsertSectionAfter("middle", "a"), t)
+	expectValues([]string{"first", "b", "a", "middle", "last"}, doc.GetSections(), t)
+	if !strings.HasPrefix(doc.String(), "[first]\n[b]\n") || !strings.HasSuffix(doc.String(), "w = 0\n[last]\n") {
+		t.Errorf("unexpected document\n%s", doc.String())
+	}
+	if err := doc.InsertSectionBefore("x", "missing"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	if err := doc.InsertSectionAfter("a", "b"); err != DuplicateSectionError {
+		t.Errorf("expected DuplicateSectionError, got %v", err)
+	}
+}
+
+func TestSetAfter(t *testing.T) {
+	doc, _ := NewDocumentFromString(orderingDocument)
+	assertErrorIsNil(doc.SetAfter("b", "y", "v", "9"), t)
+	assertErrorIsNil(doc.SetAfter("b", "y", "w", "5"), t)
+	expected := "[b]\ny = 2\nv = 9\nx = 1\n[a]\nz = 3\n# about b\n[b]\nw = 5\n"
+	expectDocumentString(expected, doc, t)
+	if err := doc.SetAfter("b", "missing", "k", "v"); err != (NoPropertyError{"missing"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+	if err := doc.SetAfter("missing", "y", "k", "v"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
+
+func TestMoveSection(t *testing.T) {
+	doc, _ := NewDocumentFromString(orderingDocument)
+	assertErrorIsNil(doc.MoveSection("b", 1), t)
+	expectDocumentString("[a]\nz = 3\n[b]\ny = 2\nx = 1\n# about b\n[b]\nw = 0\n", doc, t)
+	assertErrorIsNil(doc.MoveSection("b", 0), t)
+	expectValues([]string{"b", "a"}, doc.GetSections(), t)
+	if err := doc.MoveSection("b", 2); err != IndexOutOfRangeError {
+		t.Errorf("expected IndexOutOfRangeError, got %v", err)
+	}
+	if err := doc.MoveSection("missing", 0); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
+
+func TestSortSectionsAndKeys(t *testing.T) {
+	doc, _ := NewDocumentFromString(orderingDocument)
+	doc.SortSections(nil)
+	assertErrorIsNil(doc.SortKeys("b", nil), t)
+	expectDocumentString("[a]\nz = 3\n[b]\nx = 1\ny = 2\n# about b\n[b]\nw = 0\n", doc, t)
+	doc.SortSections(func(a, b string) bool { return a > b })
+	assertErrorIsNil(doc.SortKeys("b", func(a, b string) bool { return a > b }), t)
+	expectDocumentString("[b]\ny = 2\nx = 1\n# about b\n[b]\nw = 0\n[a]\nz = 3\n", doc, t)
+	if err := doc.SortKeys("missing", nil); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
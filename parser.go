@@ -2,13 +2,12 @@ package ini
 
 import (
 	"bufio"
-	"bytes"
 	"errors"
-	"fmt"
 	"io"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 const newline = 10
@@ -16,95 +15,464 @@ const newline = 10
 var MissingEqualSignError = errors.New("missing equal sign")
 var TooManyEqualSignsError = errors.New("too many equal signs")
 
-var assignmentPattern = regexp.MustCompile("[^\\\\]=")
-
 type lineReader struct {
-	io.ByteReader
+	// the buffer lines are scanned from if the input is an io.Reader
+	buffered *bufio.Reader
+	// the input if it is not an io.Reader. It is read byte by byte.
+	bytes io.ByteReader
+	// the bytes of the current line if they are not in the buffer
+	line []byte
+	// the number of lines read so far
+	lines int
+	// whether the end of the input was reached
+	eof bool
 }
 
-// create a new LineReader struct from any given io.ByteReader
+// create a new LineReader struct from any given io.ByteReader. If the reader
+// is also an io.Reader, it is scanned through a buffer, which may read ahead
+// of the current line.
 func newLineReader(r io.ByteReader) *lineReader {
-	return &lineReader{r}
+	switch r := r.(type) {
+	case *bufio.Reader:
+		return &lineReader{buffered: r}
+	case io.Reader:
+		return &lineReader{buffered: bufio.NewReader(r)}
+	}
+	return &lineReader{bytes: r}
 }
 
 // Read bytes from the given LineReader until a newline occurs. If the reader
 // contains no newlines, its whole content is returned. If the reader is empty,
 // i.e. contains no bytes at all, the empty string and no error is returned.
 func (r *lineReader) ReadLine() (line string, err error) {
-	var bytes []byte
-	var b byte
-	for b != newline {
-		b, err = r.ReadByte()
-		if err != nil {
-			if err == io.EOF {
-				break
+	bytes, err := r.readSlice()
+	return string(bytes), err
+}
+
+// Read the next line like ReadLine, but without copying it. The returned
+// slice is only valid until the next call.
+func (r *lineReader) readSlice() (line []byte, err error) {
+	line, err = r.scan()
+	if err == nil && len(line) == 0 {
+		r.eof = true
+	} else if err == nil {
+		r.lines++
+	}
+	return line, err
+}
+
+// scan the next line, see readSlice
+func (r *lineReader) scan() (line []byte, err error) {
+	if r.buffered == nil {
+		r.line = r.line[:0]
+		var b byte
+		for b != newline {
+			b, err = r.bytes.ReadByte()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				return r.line, err
 			}
-			return line, err
+			r.line = append(r.line, b)
+		}
+		return r.line, nil
+	}
+	line, err = r.buffered.ReadSlice(newline)
+	if err == bufio.ErrBufferFull {
+		// the line is longer than the buffer
+		r.line = append(r.line[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = r.buffered.ReadSlice(newline)
+			r.line = append(r.line, line...)
 		}
-		bytes = append(bytes, b)
+		line = r.line
+	}
+	if err == io.EOF {
+		err = nil
 	}
-	return string(bytes), nil
+	return line, err
 }
 
-// A section is a string that start with an open bracket [, ends with an open
-// bracket ] and has at least one character between those brackets.
+// A section is a string that start with an open bracket [, ends with a
+// closing bracket ] which is not escaped and has at least one character
+// between those brackets.
 func isSection(line string) bool {
 	return (strings.HasPrefix(line, "[") &&
 		strings.HasSuffix(line, "]") &&
+		!isEscaped(line, len(line)-1) &&
 		len(line) > 2)
 }
 
+// Returns true if the character at index i of the text is preceded by an odd
+// number of backslashes.
+func isEscaped(text string, i int) bool {
+	escaped := false
+	for ; i > 0 && text[i-1] == '\\'; i-- {
+		escaped = !escaped
+	}
+	return escaped
+}
+
+// Split the line at the first inline comment which begins with one of the
+// prefixes. A comment must be preceded by whitespace or begin the line. Within
+// double quotes and after a backslash, the prefixes do not count. The comment
+// is returned together with its prefix.
+func splitInlineComment(line string, prefixes []string) (content, comment string) {
+	if len(prefixes) == 0 {
+		return line, ""
+	}
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+			continue
+		case '"':
+			quoted = !quoted
+			continue
+		}
+		if quoted || (i > 0 && line[i-1] != ' ' && line[i-1] != '\t') {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if prefix != "" && strings.HasPrefix(line[i:], prefix) {
+				return strings.TrimRight(line[:i], " \t"), strings.TrimSpace(line[i:])
+			}
+		}
+	}
+	return line, ""
+}
+
+// the parts of a section header
+type sectionHeader struct {
+	name string
+	// the parents of the section, see ParseOptions.Inheritance
+	parents []string
+	// whether the header has the form [[name]], see ParseOptions.SectionArrays
+	array bool
+}
+
+// Parse the given section header according to the options. Escape sequences
+// in the name of the section and its parents are decoded, see
+// unescapeSectionName.
+func parseSectionHeader(header string, options *ParseOptions) (h sectionHeader, err error) {
+	line := header
+	if options.SectionArrays && strings.HasPrefix(header, "[[") && isSection(header[1:len(header)-1]) {
+		h.array = true
+		header = header[1 : len(header)-1]
+	}
+	if !options.Subsections && !options.Inheritance {
+		name := strings.TrimLeft(header, "[")
+		for strings.HasSuffix(name, "]") && !isEscaped(name, len(name)-1) {
+			name = name[:len(name)-1]
+		}
+		h.name, err = unescapeHeaderPart(line, name)
+		return h, err
+	}
+	h.name = header[1 : len(header)-1]
+	if options.Inheritance {
+		h.name, h.parents, err = splitParents(h.name)
+		if err != nil {
+			return h, err
+		}
+		for i, parent := range h.parents {
+			if h.parents[i], err = unescapeHeaderPart(line, parent); err != nil {
+				return h, err
+			}
+		}
+	}
+	if h.name, err = unescapeHeaderPart(line, h.name); err != nil {
+		return h, err
+	}
+	if options.Subsections {
+		section, subsection, err := SplitSubsection(h.name)
+		if err != nil {
+			return h, err
+		}
+		h.name = SubsectionName(section, subsection)
+	}
+	return h, nil
+}
+
+// Unescape the name of a section or a parent which is part of the given
+// header line. The columns of errors are counted from the beginning of the
+// line.
+func unescapeHeaderPart(line, part string) (string, error) {
+	name, err := unescapeSectionName(part)
+	if e, ok := err.(InvalidEscapeError); ok {
+		e.Column += strings.Index(line, part)
+		return "", e
+	}
+	return name, err
+}
+
+// Decode the escape sequences of a section name, see unescapeSequence.
+// Unescaped whitespace at the beginning and the end is dropped. Text within
+// double quotes is kept as it is, so that subsections like remote "a\"b" can
+// be parsed by SplitSubsection.
+func unescapeSectionName(name string) (string, error) {
+	var part itemPart
+	quoted := false
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '\\' && quoted && i+1 < len(name):
+			part.write(name[i:i+2], true)
+			i++
+		case c == '\\' && !quoted:
+			decoded, length, err := unescapeSequence(name, i)
+			if err != nil {
+				return "", err
+			}
+			part.write(decoded, true)
+			i += length - 1
+		case c == '"':
+			quoted = !quoted
+			part.write(`"`, false)
+		default:
+			part.write(name[i:i+1], false)
+		}
+	}
+	return part.String(), nil
+}
+
+// Decode the escape sequences of a property name which is written without a
+// value or after !unset, see unescapeSequence. Unescaped whitespace at the
+// beginning and the end is dropped.
+func unescapePropertyName(name string) (string, error) {
+	var part itemPart
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			part.write(name[i:i+1], false)
+			continue
+		}
+		decoded, length, err := unescapeSequence(name, i)
+		if err != nil {
+			return "", err
+		}
+		part.write(decoded, true)
+		i += length - 1
+	}
+	return part.String(), nil
+}
+
 type Item struct {
 	Property string
 	Value    string
 }
 
-// Convert escaped control characters to their unescaped form. Additionally,
-// convert \= to = to allow equal signs within values.
-// Specifically, this function performs the following conversions:
-//    \\\\    ->    \\
-//     \\0    ->    \0
-//     \\a    ->    \a
-//     \\b    ->    \b
-//     \\t    ->    \t
-//     \\r    ->    \r
-//     \\n    ->    \n
-//      \=    ->    =
-func unescapeControlCharacters(value string) string {
-	var replacements = map[string]string{
-		`\\`: `\`,
-		`\0`: "\x00",
-		`\a`: "\a",
-		`\b`: "\b",
-		`\t`: "\t",
-		`\r`: "\r",
-		`\n`: "\n",
-		`\=`: "="}
-	for old, new := range replacements {
-		value = strings.Replace(value, old, new, -1)
-	}
-	return value
+// the escape sequences consisting of a backslash and a single character
+var escapeSequences = map[byte]string{
+	'\\': `\`,
+	'0':  "\x00",
+	'a':  "\a",
+	'b':  "\b",
+	't':  "\t",
+	'r':  "\r",
+	'n':  "\n",
+	'=':  "=",
+	';':  ";",
+	'#':  "#",
+	':':  ":",
+	'"':  `"`,
+	'[':  "[",
+	']':  "]",
+	'!':  "!"}
+
+// the number of hexadecimal digits following \x, \u and \U
+var hexEscapeDigits = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// Decode the escape sequence starting with the backslash at index i of the
+// text. Besides the escape sequences with a single character, these forms
+// are supported:
+//
+//	\xNN        the byte with the hexadecimal value NN
+//	\uNNNN      the Unicode code point U+NNNN
+//	\UNNNNNNNN  the Unicode code point U+NNNNNNNN
+//
+// The decoded text and the length of the escape sequence are returned. If the
+// escape sequence is unknown or incomplete, InvalidEscapeError is returned.
+func unescapeSequence(text string, i int) (decoded string, length int, err error) {
+	if i+1 == len(text) {
+		return "", 0, InvalidEscapeError{i + 1, `\`}
+	}
+	if decoded, ok := escapeSequences[text[i+1]]; ok {
+		return decoded, 2, nil
+	}
+	digits, ok := hexEscapeDigits[text[i+1]]
+	if !ok {
+		_, size := utf8.DecodeRuneInString(text[i+1:])
+		return "", 0, InvalidEscapeError{i + 1, text[i : i+1+size]}
+	}
+	length = 2 + digits
+	if i+length > len(text) {
+		return "", 0, InvalidEscapeError{i + 1, text[i:]}
+	}
+	value, err := strconv.ParseUint(text[i+2:i+length], 16, 32)
+	if err != nil {
+		return "", 0, InvalidEscapeError{i + 1, text[i : i+length]}
+	}
+	if text[i+1] == 'x' {
+		return string([]byte{byte(value)}), length, nil
+	}
+	if !utf8.ValidRune(rune(value)) {
+		return "", 0, InvalidEscapeError{i + 1, text[i : i+length]}
+	}
+	return string(rune(value)), length, nil
+}
+
+// The unescaped text of a property or a value. Unescaped whitespace at the
+// beginning and the end is dropped, escaped whitespace is kept.
+type itemPart struct {
+	strings.Builder
+	// the length of the text without trailing unescaped whitespace
+	end int
+}
+
+// the whitespace which is dropped at the beginning and the end of a name or a
+// value unless it is escaped
+const unescapedWhitespace = " \t\r\n\v\f"
+
+func (p *itemPart) write(s string, escaped bool) {
+	if !escaped && len(s) == 1 && strings.IndexByte(unescapedWhitespace, s[0]) >= 0 {
+		if p.Len() > 0 {
+			p.WriteString(s)
+		}
+		return
+	}
+	p.WriteString(s)
+	p.end = p.Len()
+}
+
+func (p *itemPart) String() string {
+	return p.Builder.String()[:p.end]
+}
+
+// Unescape all escape sequences of the text, see unescapeSequence. Positions
+// in errors are counted from the beginning of the text, starting at 1.
+func unescape(text string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' {
+			buf.WriteByte(text[i])
+			continue
+		}
+		decoded, length, err := unescapeSequence(text, i)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(decoded)
+		i += length - 1
+	}
+	return buf.String(), nil
 }
 
 // An assignment is of the form `name=value`. Whitespace before and after the
 // equal sign is ignored. Equals signs within the value must be quoted or
-// escaped with the backslash.
+// escaped with the backslash. The line is read in a single pass: escape
+// sequences are decoded in the name and the value, see unescapeSequence, and
+// invalid ones are reported as InvalidEscapeError with their column.
 func parseItem(line string) (item *Item, err error) {
-	matches := assignmentPattern.FindAllStringIndex(line, -1)
-	if matches == nil {
+	// most lines contain neither escape sequences nor quotes, so that the
+	// name and the value can be cut out of the line
+	if !strings.ContainsAny(line, `\"`) {
+		separator := strings.IndexByte(line, '=')
+		switch {
+		case separator < 0:
+			return item, MissingEqualSignError
+		case strings.IndexByte(line[separator+1:], '=') >= 0:
+			return item, TooManyEqualSignsError
+		}
+		property := strings.Trim(line[:separator], unescapedWhitespace)
+		if property == "" {
+			return item, MissingPropertyError
+		}
+		return &Item{property, strings.Trim(line[separator+1:], unescapedWhitespace)}, nil
+	}
+	var property, value itemPart
+	current := &property
+	separator := -1
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\':
+			decoded, length, err := unescapeSequence(line, i)
+			if err != nil {
+				return item, err
+			}
+			current.write(decoded, true)
+			i += length - 1
+		case c == '"' && separator >= 0:
+			quoted = !quoted
+			current.write(`"`, false)
+		case c == '=' && !quoted:
+			if separator >= 0 {
+				return item, TooManyEqualSignsError
+			}
+			separator = i
+			current = &value
+		default:
+			current.write(line[i:i+1], false)
+		}
+	}
+	if separator < 0 {
 		return item, MissingEqualSignError
 	}
-	if len(matches) > 1 {
-		return item, TooManyEqualSignsError
+	if property.String() == "" {
+		return item, MissingPropertyError
 	}
-	loc := matches[0]
-	property := strings.TrimSpace(line[:loc[0]+1])
-	value := strings.TrimSpace(line[loc[1]:])
-	value = unescapeControlCharacters(value)
-	item = &Item{property, value}
+	item = &Item{property.String(), value.String()}
 	return
 }
 
+// An assignment is an item together with the way it was written.
+type assignment struct {
+	Item
+	op AssignmentOperator
+	// written as key[] = value, see ParseOptions.ArrayKeys
+	array bool
+	// written without a value, see ParseOptions.AllowNoValue
+	flag bool
+}
+
+// Parse a line which is neither a comment nor a section header. Depending on
+// the options, the line may also be of the form "key += value", "!unset key",
+// "key[] = value" or just "key".
+func parseAssignment(line string, options *ParseOptions) (a *assignment, err error) {
+	a = &assignment{}
+	if options.Operators {
+		trimmedLine := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmedLine, unsetKeyword) {
+			property := strings.TrimSpace(trimmedLine[len(unsetKeyword):])
+			if property == "" || property == trimmedLine[len(unsetKeyword):] {
+				return a, MissingPropertyError
+			}
+			a.Property, err = unescapePropertyName(property)
+			a.op = OperatorUnset
+			return a, err
+		}
+	}
+	item, err := parseItem(line)
+	// a broken section header is not a flag
+	if err == MissingEqualSignError && options.AllowNoValue && !strings.HasPrefix(strings.TrimSpace(line), "[") {
+		a.Property, err = unescapePropertyName(line)
+		a.flag = true
+		return a, err
+	}
+	if err != nil {
+		return a, err
+	}
+	a.Item = *item
+	if options.Operators && strings.HasSuffix(a.Property, "+") {
+		a.Property = strings.TrimSpace(strings.TrimSuffix(a.Property, "+"))
+		a.op = OperatorAppend
+	}
+	if options.ArrayKeys {
+		a.Property, a.array = splitArrayKey(a.Property)
+	}
+	return a, nil
+}
+
 // a Config map maps from section names to maps of assignments
 type Config map[string]map[string]string
 
@@ -115,6 +483,19 @@ func NewConfig() *Config {
 	return &c
 }
 
+// Return a deep copy of the config. Changing the copy does not affect the
+// original config and vice versa.
+func (c *Config) Copy() *Config {
+	copied := make(Config)
+	for section, properties := range *c {
+		copied[section] = make(map[string]string)
+		for property, value := range properties {
+			copied[section][property] = value
+		}
+	}
+	return &copied
+}
+
 // Create a new *Config from a string. This is a shortcut for:
 //	NewConfigFromByteReader(strings.NewReader(s))
 func NewConfigFromString(s string) (*Config, error) {
@@ -139,7 +520,13 @@ func NewConfigFromFilename(filename string) (*Config, error) {
 
 // Create a new *Config from a ByteReader.
 func NewConfigFromByteReader(reader io.ByteReader) (*Config, error) {
-	return parseINI(newLineReader(reader))
+	return parseConfigSource("", reader, nil)
+}
+
+// Create a new *Config from a ByteReader using the given options. If options
+// is nil, the result is the same as with NewConfigFromByteReader.
+func ParseConfig(reader io.ByteReader, options *ParseOptions) (*Config, error) {
+	return parseConfigSource("", reader, options)
 }
 
 // Parse the given *LineReader to a *Config. If the reader is empty, an empty
@@ -147,13 +534,29 @@ func NewConfigFromByteReader(reader io.ByteReader) (*Config, error) {
 // does not belong to any section, i.e. if it was written before the first
 // section was declared. Other errors are syntax errors: Examples for syntax
 // errors are: no equals sign in an assignment, more than one unescaped equal
-// sign in an assignment.
-func parseINI(reader *lineReader) (*Config, error) {
+// sign in an assignment. These errors are returned as they are, all other
+// syntax errors, e.g. an InvalidEscapeError, as a ParseError with the number
+// of the line. Repeated sections and properties are handled as requested by
+// the options.
+func parseINI(reader *lineReader, options *ParseOptions) (*Config, error) {
 	conf := make(Config)
 	var line string
 	var err error
 	var section string
-	for {
+	if options.DuplicateSections == DuplicateKeepAll || options.DuplicateProperties == DuplicateKeepAll {
+		return &conf, UnsupportedPolicyError
+	}
+	duplicates := newDuplicateTracker(options)
+	// a Config keeps the last value of a property anyway, so repeated
+	// assignments only need to be tracked if they are rejected or ignored
+	trackProperties := options.DuplicateProperties == DuplicateError ||
+		options.DuplicateProperties == DuplicateFirstWins
+	names := newSpellings(options.CaseFolding)
+	inheritance := make(Inheritance)
+	// whether the properties of the current section are ignored because
+	// the section was declared before
+	skipSection := false
+	for lineNumber := 1; ; lineNumber++ {
 		line, err = reader.ReadLine()
 		if err != nil {
 			return &conf, err
@@ -162,24 +565,65 @@ func parseINI(reader *lineReader) (*Config, error) {
 			// stop reading at EOF
 			break
 		}
+		line, _ = splitInlineComment(line, options.InlineCommentPrefixes)
 		trimmedLine := strings.TrimSpace(line)
+		// ignore lines consisting only of whitespace
+		if trimmedLine == "" {
+			continue
+		}
 		// ignore lines beginning with # or ;
 		firstCharacter := trimmedLine[0]
 		if firstCharacter == '#' || firstCharacter == ';' {
 			continue
 		}
 		if isSection(trimmedLine) {
-			section = strings.Trim(trimmedLine, "[]")
+			header, err := parseSectionHeader(trimmedLine, options)
+			if err != nil {
+				return &conf, lineError(lineNumber, err)
+			}
+			section = names.section(header.name)
+			for i, parent := range header.parents {
+				header.parents[i] = names.section(parent)
+			}
+			inheritance.add(section, header.parents)
+			// blocks of the form [[name]] are meant to be repeated
+			repeated := false
+			if header.array {
+				duplicates.record(section, lineNumber)
+			} else if repeated, err = duplicates.section(section, lineNumber); err != nil {
+				return &conf, err
+			}
+			skipSection = repeated && options.DuplicateSections == DuplicateFirstWins
+			if repeated && options.DuplicateSections == DuplicateLastWins {
+				conf.RemoveSection(section)
+			}
 			conf.AddSection(section)
 		} else {
 			// If the line is not a section, it must be an
 			// assignment. Otherwise it's a syntax error
-			item, err := parseItem(line)
+			a, err := parseAssignment(line, options)
 			if err != nil {
-				return &conf, err
+				return &conf, lineError(lineNumber, err)
 			}
 			if section != "" {
-				conf.Set(section, item.Property, item.Value)
+				if skipSection {
+					continue
+				}
+				// properties of the form key[] = value and
+				// operators other than = are meant to be used
+				// several times
+				repeated := false
+				if trackProperties && !a.array && a.op == OperatorAssign {
+					repeated, err = duplicates.property(section, a.Property, lineNumber)
+					if err != nil {
+						return &conf, err
+					}
+				}
+				if repeated && options.DuplicateProperties == DuplicateFirstWins {
+					continue
+				}
+				a.Property = names.property(section, a.Property)
+				conf.applyOperator(section, &a.Item, a.op, options)
 			} else {
 				// assignment outside a section.
 				// this is a syntax error
@@ -187,27 +631,36 @@ func parseINI(reader *lineReader) (*Config, error) {
 			}
 		}
 	}
+	if options.Inheritance {
+		return conf.Flatten(inheritance)
+	}
 	return &conf, nil
 }
 
+// Return the error of the given line as a ParseError. The syntax errors which
+// the parser of a Config has always returned as they are stay unchanged.
+func lineError(line int, err error) error {
+	switch err {
+	case MissingEqualSignError, TooManyEqualSignsError, AssignmentOutsideSectionError:
+		return err
+	}
+	return ParseError{line, err}
+}
+
 // Return a normalized representation of the config. The order of sections and
 // the order of assignments within each section is non-deterministic. Each
 // section declaration begins with an open bracket [ and end with a closing
 // bracket ] plus a newline \n. An Assignment starts with a property, followed
 // by an equal sign which is enclosed in spaces and ends with a value and a
-// newline.
+// newline. Properties and values are escaped where necessary, so that parsing
+// the result gives the same config again. Unlike WriteTo, sections and
+// properties with invalid names, e.g. an empty property, are written as they
+// are.
 func (c *Config) String() string {
-	buf := new(bytes.Buffer)
-	for _, section := range c.GetSections() {
-		buf.WriteString(fmt.Sprintf("[%s]\n", section))
-		// error can be ignored because the section surely exists
-		items, _ := c.GetItems(section)
-		for _, item := range items {
-			buf.WriteString(fmt.Sprintf("%s = %s\n", item.Property, item.Value))
-		}
-	}
-	// TODO: this looks inefficient and ugly. find some better way to cut of
-	// the last byte of buf
+	var buf strings.Builder
+	e := NewEncoder(&buf, nil)
+	e.unchecked = true
+	e.Encode(c)
 	// remove trailing linebreak to be consistent with empty *Config values
-	return strings.TrimSpace(string(buf.Bytes()))
+	return strings.TrimSuffix(buf.String(), "\n")
 }
@@ -2,8 +2,11 @@ package ini
 
 import (
 	"fmt"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/quick"
 )
 
 type tests []struct {
@@ -65,6 +68,24 @@ func TestReadline(t *testing.T) {
 	expectLine("first line\n", line, t)
 }
 
+// a ByteReader which is not an io.Reader
+type byteReader struct {
+	io.ByteReader
+}
+
+func TestReadlineLongLines(t *testing.T) {
+	long := strings.Repeat("x", 10000) + "\n"
+	for _, reader := range []io.ByteReader{strings.NewReader(long + "end"), byteReader{strings.NewReader(long + "end")}} {
+		linereader := newLineReader(reader)
+		line, err := linereader.ReadLine()
+		assertErrorIsNil(err, t)
+		expectLine(long, line, t)
+		line, err = linereader.ReadLine()
+		assertErrorIsNil(err, t)
+		expectLine("end", line, t)
+	}
+}
+
 func TestIsSectionEmptyString(t *testing.T) {
 	assertIsNotSection("", t)
 }
@@ -81,26 +102,44 @@ func TestIsSectionValid(t *testing.T) {
 	assertIsSection("[validsection]", t)
 }
 
-func TestUnescapeControlCharactersEscaped(t *testing.T) {
+func TestUnescapeEscaped(t *testing.T) {
 	var unescapeTests = tests{
 		{`a\rb`, "a\rb"},
 		{`c\nd`, "c\nd"},
 		{`e\tf`, "e\tf"},
 		{`g\=h`, "g=h"},
-		{`k\\l`, `k\l`}}
-	var unescapedValue string
+		{`k\\l`, `k\l`},
+		{`\\n`, `\n`},
+		{`\\\n`, "\\\n"},
+		{`\#\;\:\"`, `#;:"`},
+		{`\x41\x7e`, "A~"},
+		{`\u00e4\u20AC`, "ä€"},
+		{`\U0001F600`, "\U0001F600"}}
 	for _, test := range unescapeTests {
-		unescapedValue = unescapeControlCharacters(test.in)
+		unescapedValue, err := unescape(test.in)
+		assertErrorIsNil(err, t)
 		if unescapedValue != test.out {
 			t.Errorf("expected %q, got %q", test.out, unescapedValue)
 		}
 	}
 }
 
-func TestUnescapeControlCharactersUnsupportedEscape(t *testing.T) {
-	unescapedValue := unescapeControlCharacters(`\#`)
-	if unescapedValue != `\#` {
-		t.Errorf(`expected \#, got %q`, unescapedValue)
+func TestUnescapeInvalidEscape(t *testing.T) {
+	cases := []struct {
+		in  string
+		err InvalidEscapeError
+	}{
+		{`\d`, InvalidEscapeError{1, `\d`}},
+		{`ab\`, InvalidEscapeError{3, `\`}},
+		{`a\x4`, InvalidEscapeError{2, `\x4`}},
+		{`\xZZ`, InvalidEscapeError{1, `\xZZ`}},
+		{`\uD800`, InvalidEscapeError{1, `\uD800`}},
+		{`\U00110000`, InvalidEscapeError{1, `\U00110000`}},
+		{`\ä`, InvalidEscapeError{1, `\ä`}}}
+	for _, c := range cases {
+		if _, err := unescape(c.in); err != c.err {
+			t.Errorf("%q: expected %v, got %v", c.in, c.err, err)
+		}
 	}
 }
 
@@ -178,8 +217,55 @@ func TestParseItemWithEscapedBackslash(t *testing.T) {
 	expectValue("bar \\ baz", item.Value, t)
 }
 
-// TODO: test parseItem with quoted values!
-func TestParseItemWithDoubleQuotes(t *testing.T) {}
+func TestParseItemWithDoubleQuotes(t *testing.T) {
+	item, err := parseItem(`foo = "a=b" c`)
+	assertErrorIsNil(err, t)
+	expectProperty("foo", item.Property, t)
+	expectValue(`"a=b" c`, item.Value, t)
+}
+
+func TestParseItemEscapedBackslashBeforeEqualSign(t *testing.T) {
+	item, err := parseItem(`foo\\=bar`)
+	assertErrorIsNil(err, t)
+	expectProperty(`foo\`, item.Property, t)
+	expectValue("bar", item.Value, t)
+	item, err = parseItem(`ä=ö`)
+	assertErrorIsNil(err, t)
+	expectProperty("ä", item.Property, t)
+	expectValue("ö", item.Value, t)
+}
+
+func TestParseItemMissingProperty(t *testing.T) {
+	for _, line := range []string{"=value", "  = value"} {
+		if _, err := parseItem(line); err != MissingPropertyError {
+			t.Errorf("%q: expected MissingPropertyError, got %v", line, err)
+		}
+	}
+}
+
+func TestParseItemEscapedWhitespace(t *testing.T) {
+	item, err := parseItem(`foo = \tbar\x20  `)
+	assertErrorIsNil(err, t)
+	expectValue("\tbar ", item.Value, t)
+}
+
+func TestParseItemInvalidEscape(t *testing.T) {
+	_, err := parseItem(`path = C:\dir`)
+	if err != (InvalidEscapeError{10, `\d`}) {
+		t.Errorf("expected InvalidEscapeError in column 10, got %v", err)
+	}
+	_, err = NewDocumentFromString("[a]\nx = 1\npath = C:\\dir\n")
+	if err != (ParseError{3, InvalidEscapeError{10, `\d`}}) {
+		t.Errorf("expected a ParseError in line 3, got %v", err)
+	}
+	_, err = NewConfigFromString("[a]\nx = 1\npath = C:\\dir\n")
+	if err != (ParseError{3, InvalidEscapeError{10, `\d`}}) {
+		t.Errorf("expected a ParseError in line 3, got %v", err)
+	}
+	if _, err = NewConfigFromString("[a]\nx\n"); err != MissingEqualSignError {
+		t.Errorf("expected MissingEqualSignError, got %v", err)
+	}
+}
 
 func TestParseINIEmpty(t *testing.T) {
 	config, err := NewConfigFromString("")
@@ -276,3 +362,174 @@ func ExampleConfig_String() {
 	// [section]
 	// foo = bar
 }
+
+func TestParseINIBlankLines(t *testing.T) {
+	config, err := NewConfigFromString("\n[section]\n  \t\nproperty=value\n\n")
+	assertErrorIsNil(err, t)
+	expectedConfig := &Config{"section": {"property": "value"}}
+	assertConfigMapsEqual(config, expectedConfig, t)
+}
+
+func TestSplitInlineComment(t *testing.T) {
+	prefixes := []string{"#", ";", "//"}
+	cases := []struct {
+		line, content, comment string
+	}{
+		{"a = b # comment", "a = b", "# comment"},
+		{"a = b\t; comment  ", "a = b", "; comment"},
+		{"url = http://example.com // home", "url = http://example.com", "// home"},
+		{"a = b#c", "a = b#c", ""},
+		{`a = "b # c" # d`, `a = "b # c"`, "# d"},
+		{`a = b \# c`, `a = b \# c`, ""},
+		{"[section] # note", "[section]", "# note"},
+		{"// whole line", "", "// whole line"},
+		{"a = b", "a = b", ""}}
+	for _, c := range cases {
+		content, comment := splitInlineComment(c.line, prefixes)
+		if content != c.content || comment != c.comment {
+			t.Errorf("%q: expected %q and %q, got %q and %q", c.line, c.content, c.comment, content, comment)
+		}
+	}
+	if content, _ := splitInlineComment("a = b # c", nil); content != "a = b # c" {
+		t.Errorf("expected no comment without prefixes, got %q", content)
+	}
+}
+
+func TestParseConfigInlineComments(t *testing.T) {
+	input := "[section] ; the section\nproperty = value # this is a comment\n// note\n"
+	options := &ParseOptions{InlineCommentPrefixes: []string{"#", ";", "//"}}
+	conf, err := ParseConfig(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"section": {"property": "value"}}, t)
+	conf, err = NewConfigFromString("[section]\nproperty = value # this is not a comment\n")
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"section": {"property": "value # this is not a comment"}}, t)
+}
+
+func TestEscape(t *testing.T) {
+	var escapeTests = tests{
+		{"plain value", "plain value"},
+		{"a=b", `a\=b`},
+		{`C:\dir`, `C:\\dir`},
+		{"  padded  ", `\x20\x20padded\x20\x20`},
+		{"line\nbreak\ttab", `line\nbreak\ttab`},
+		{"# not a comment ; either", `\# not a comment \; either`},
+		{"a#b;c", "a#b;c"},
+		{"\x00\x1b\x7f", `\0\x1b\x7f`},
+		{"\xff", `\xff`},
+		{"ä€", "ä€"},
+		{"\u2028x\u00a0y\u00a0", "\\u2028x\u00a0y\\u00a0"}}
+	for _, test := range escapeTests {
+		if escaped := escape(test.in, nil); escaped != test.out {
+			t.Errorf("expected %q, got %q", test.out, escaped)
+		}
+	}
+}
+
+// Check that parsing the result of String gives the config again.
+func checkConfigRoundTrip(conf *Config, t *testing.T) bool {
+	parsed, err := NewConfigFromString(conf.String())
+	if err != nil {
+		t.Errorf("cannot parse %q: %v", conf.String(), err)
+		return false
+	}
+	if !reflect.DeepEqual(parsed, conf) {
+		t.Errorf("expected %#v, got %#v", conf, parsed)
+		return false
+	}
+	return true
+}
+
+func TestConfigStringRoundTrip(t *testing.T) {
+	conf := &Config{"section": {
+		"a=b":       "c=d",
+		" key ":     " value ",
+		"#comment":  ";comment",
+		"escapes":   `\n \t \\`,
+		"controls":  "\x00\r\n\t\x1b",
+		"invalid":   "\xff\xfe",
+		"empty":     "",
+		"quoted":    `"a=b" # c`,
+		"unicode ä": "€ \u2028"}}
+	checkConfigRoundTrip(conf, t)
+	checkConfigRoundTrip(&Config{
+		"[a]":           {"[b": "c]", "!unset x": "y"},
+		" a\\b ":        {"x": "y"},
+		"a\\]":          {"x": "y"},
+		`a "b`:          {"x": "y"},
+		`remote "a\"b"`: {"x": "y"},
+		"#a: b":         {"x": "y"}}, t)
+	f := func(section, property, value string) bool {
+		if section == "" || property == "" {
+			return true
+		}
+		return checkConfigRoundTrip(&Config{section: {property: value}}, t)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseEscapedNames(t *testing.T) {
+	input := `[ a\]\x20]
+\[b] = 1
+\!unset = 2
+[c\: d : e]
+x = y
+[remote "a\"b\\c"]
+z = 3
+`
+	conf, err := NewConfigFromString(input)
+	assertErrorIsNil(err, t)
+	for _, section := range []string{"a] ", "c: d : e", `remote "a\"b\\c"`} {
+		if !conf.HasSection(section) {
+			t.Errorf("expected the section %q, got %v", section, conf.GetSections())
+		}
+	}
+	value, _ := conf.Get("a] ", "[b]")
+	expectValue("1", value, t)
+	value, _ = conf.Get("a] ", "!unset")
+	expectValue("2", value, t)
+	options := &ParseOptions{Inheritance: true, Subsections: true, Operators: true}
+	doc, err := ParseDocument(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	expectValues([]string{"a] ", "c: d", `remote "a\"b\\c"`}, doc.GetSections(), t)
+	expectValues([]string{"e"}, doc.Sections[1].Parents, t)
+	expectDocumentString(input, doc, t)
+	doc.Normalize()
+	expectDocumentString(`[a\]\x20]
+\[b] = 1
+\!unset = 2
+[c\: d : e]
+x = y
+[remote "a\"b\\c"]
+z = 3
+`, doc, t)
+	if _, err := NewConfigFromString("[a\\q]\n"); err != (ParseError{1, InvalidEscapeError{3, `\q`}}) {
+		t.Errorf("expected InvalidEscapeError in line 1, got %v", err)
+	}
+	if _, err := NewConfigFromString("[a\\]\n"); err != MissingEqualSignError {
+		t.Errorf("expected MissingEqualSignError, got %v", err)
+	}
+}
+
+func TestDocumentStringRoundTrip(t *testing.T) {
+	f := func(property, value string) bool {
+		if property == "" {
+			return true
+		}
+		doc := NewDocument()
+		doc.AddSection("section")
+		doc.Set("section", property, value)
+		parsed, err := NewDocumentFromString(doc.String())
+		if err != nil {
+			t.Errorf("cannot parse %q: %v", doc.String(), err)
+			return false
+		}
+		parsedValue, err := parsed.Get("section", property)
+		return err == nil && parsedValue == value
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,382 @@
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A PatchOperation describes what kind of change a PatchStep performs.
+type PatchOperation int
+
+const (
+	// set the property of a section to a value
+	PatchSet PatchOperation = iota
+	// remove a property from a section
+	PatchUnset
+	// add a new, empty section
+	PatchAddSection
+	// remove a section including all of its properties
+	PatchRemoveSection
+	// rename a section, keeping its properties
+	PatchRenameSection
+)
+
+var patchOperationNames = map[PatchOperation]string{
+	PatchSet:           "set",
+	PatchUnset:         "unset",
+	PatchAddSection:    "add-section",
+	PatchRemoveSection: "remove-section",
+	PatchRenameSection: "rename-section"}
+
+func (op PatchOperation) String() string {
+	if name, ok := patchOperationNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("PatchOperation(%d)", int(op))
+}
+
+// A PatchStep is a single change of a Patch. Which fields are used depends on
+// the operation:
+//
+//	PatchSet:           Section, Property, Value
+//	PatchUnset:         Section, Property
+//	PatchAddSection:    Section
+//	PatchRemoveSection: Section
+//	PatchRenameSection: Section (old name), Value (new name)
+//
+// If CheckOldValue is true, a PatchSet or PatchUnset step is only applied if
+// the property currently exists and has the value OldValue.
+type PatchStep struct {
+	Operation     PatchOperation
+	Section       string
+	Property      string
+	Value         string
+	OldValue      string
+	CheckOldValue bool
+}
+
+// A Patch is an ordered list of changes which can be applied to a *Config.
+type Patch []PatchStep
+
+// Return the textual representation of the step. Each field is written as a
+// double quoted Go string literal, e.g.
+//
+//	set "section" "property" "new value" from "old value"
+func (step PatchStep) String() string {
+	fields := []string{step.Operation.String(), strconv.Quote(step.Section)}
+	switch step.Operation {
+	case PatchSet:
+		fields = append(fields, strconv.Quote(step.Property), strconv.Quote(step.Value))
+	case PatchUnset:
+		fields = append(fields, strconv.Quote(step.Property))
+	case PatchRenameSection:
+		fields = append(fields, strconv.Quote(step.Value))
+	}
+	if step.CheckOldValue {
+		fields = append(fields, "from", strconv.Quote(step.OldValue))
+	}
+	return strings.Join(fields, " ")
+}
+
+// Return the textual representation of the patch, one step per line. The
+// result can be read back with ParsePatch.
+func (p Patch) String() string {
+	buf := new(bytes.Buffer)
+	for _, step := range p {
+		buf.WriteString(step.String())
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// A patchField is a field of a line of a patch. Fields are either bare words
+// (operations and the keyword "from") or double quoted Go string literals.
+type patchField struct {
+	text   string
+	quoted bool
+}
+
+func splitPatchLine(line string) (fields []patchField, err error) {
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return fields, nil
+		}
+		if line[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return fields, err
+			}
+			text, err := strconv.Unquote(quoted)
+			if err != nil {
+				return fields, err
+			}
+			fields = append(fields, patchField{text, true})
+			line = line[len(quoted):]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end == -1 {
+			end = len(line)
+		}
+		word := line[:end]
+		if strings.ContainsRune(word, '"') {
+			return fields, fmt.Errorf("unexpected quote in %q", word)
+		}
+		fields = append(fields, patchField{word, false})
+		line = line[end:]
+	}
+}
+
+// Parse a patch in the format written by Patch.String. Empty lines and lines
+// starting with # or ; are ignored.
+func ParsePatch(s string) (Patch, error) {
+	patch := Patch{}
+	for i, line := range strings.Split(s, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || trimmedLine[0] == '#' || trimmedLine[0] == ';' {
+			continue
+		}
+		step, err := parsePatchStep(trimmedLine)
+		if err != nil {
+			return patch, PatchSyntaxError{i + 1, err.Error()}
+		}
+		patch = append(patch, step)
+	}
+	return patch, nil
+}
+
+func parsePatchStep(line string) (step PatchStep, err error) {
+	splitFields, err := splitPatchLine(line)
+	if err != nil {
+		return step, err
+	}
+	if n := len(splitFields); n > 2 && splitFields[n-2] == (patchField{"from", false}) {
+		if !splitFields[n-1].quoted {
+			return step, fmt.Errorf("expected a quoted old value, got %q", splitFields[n-1].text)
+		}
+		step.OldValue = splitFields[n-1].text
+		step.CheckOldValue = true
+		splitFields = splitFields[:n-2]
+	}
+	if splitFields[0].quoted {
+		return step, fmt.Errorf("expected an operation, got %q", splitFields[0].text)
+	}
+	fields := []string{}
+	for i, field := range splitFields {
+		if i > 0 && !field.quoted {
+			return step, fmt.Errorf("expected a quoted argument, got %q", field.text)
+		}
+		fields = append(fields, field.text)
+	}
+	found := false
+	for op, name := range patchOperationNames {
+		if fields[0] == name {
+			step.Operation = op
+			found = true
+		}
+	}
+	if !found {
+		return step, fmt.Errorf("unknown operation %q", fields[0])
+	}
+	var expectedFields int
+	switch step.Operation {
+	case PatchSet:
+		expectedFields = 4
+	case PatchUnset, PatchRenameSection:
+		expectedFields = 3
+	default:
+		expectedFields = 2
+	}
+	if len(fields) != expectedFields {
+		return step, fmt.Errorf("%s expects %d arguments, got %d",
+			fields[0], expectedFields-1, len(fields)-1)
+	}
+	if step.CheckOldValue && step.Operation != PatchSet && step.Operation != PatchUnset {
+		return step, fmt.Errorf("%s does not accept an old value", fields[0])
+	}
+	step.Section = fields[1]
+	switch step.Operation {
+	case PatchSet:
+		step.Property = fields[2]
+		step.Value = fields[3]
+	case PatchUnset:
+		step.Property = fields[2]
+	case PatchRenameSection:
+		step.Value = fields[2]
+	}
+	return step, nil
+}
+
+// Check the preconditions of the step against the config and return the
+// steps which undo it.
+func (step PatchStep) inverse(c *Config) (Patch, error) {
+	switch step.Operation {
+	case PatchSet, PatchUnset:
+		if !c.HasSection(step.Section) {
+			return nil, NoSectionError
+		}
+		oldValue, exists := (*c)[step.Section][step.Property]
+		if step.CheckOldValue {
+			if !exists {
+				return nil, NoPropertyError{step.Property}
+			}
+			if oldValue != step.OldValue {
+				return nil, PatchConflictError{step.Section, step.Property, step.OldValue, oldValue}
+			}
+		}
+		if step.Operation == PatchUnset {
+			if !exists {
+				return nil, NoPropertyError{step.Property}
+			}
+			return Patch{{Operation: PatchSet, Section: step.Section,
+				Property: step.Property, Value: oldValue}}, nil
+		}
+		if !exists {
+			return Patch{{Operation: PatchUnset, Section: step.Section,
+				Property: step.Property, OldValue: step.Value, CheckOldValue: true}}, nil
+		}
+		return Patch{{Operation: PatchSet, Section: step.Section,
+			Property: step.Property, Value: oldValue,
+			OldValue: step.Value, CheckOldValue: true}}, nil
+	case PatchAddSection:
+		if c.HasSection(step.Section) {
+			return nil, DuplicateSectionError
+		}
+		return Patch{{Operation: PatchRemoveSection, Section: step.Section}}, nil
+	case PatchRemoveSection:
+		if !c.HasSection(step.Section) {
+			return nil, NoSectionError
+		}
+		return sectionPatch(c, step.Section), nil
+	case PatchRenameSection:
+		if !c.HasSection(step.Section) {
+			return nil, NoSectionError
+		}
+		if c.HasSection(step.Value) {
+			return nil, DuplicateSectionError
+		}
+		return Patch{{Operation: PatchRenameSection, Section: step.Value, Value: step.Section}}, nil
+	}
+	return nil, fmt.Errorf("unknown operation %v", step.Operation)
+}
+
+// apply the step without checking any preconditions
+func (step PatchStep) apply(c *Config) {
+	switch step.Operation {
+	case PatchSet:
+		(*c)[step.Section][step.Property] = step.Value
+	case PatchUnset:
+		delete((*c)[step.Section], step.Property)
+	case PatchAddSection:
+		(*c)[step.Section] = make(map[string]string)
+	case PatchRemoveSection:
+		delete(*c, step.Section)
+	case PatchRenameSection:
+		c.RenameSection(step.Section, step.Value)
+	}
+}
+
+// Return the steps which recreate the given section of the config with all
+// of its properties, sorted by property name.
+func sectionPatch(c *Config, section string) Patch {
+	patch := Patch{{Operation: PatchAddSection, Section: section}}
+	for _, property := range sortedKeys((*c)[section]) {
+		patch = append(patch, PatchStep{Operation: PatchSet, Section: section,
+			Property: property, Value: (*c)[section][property]})
+	}
+	return patch
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Apply all steps of the patch to the config. Before a step is applied, its
+// preconditions are checked: sections which are changed must exist, sections
+// which are added must not, and old values must match if they are given. If
+// any step fails, a PatchError is returned and the config is left unchanged.
+func (p Patch) Apply(c *Config) error {
+	_, err := p.applyTo(c)
+	return err
+}
+
+// Return a patch which undoes p when it is applied to the result of applying
+// p to the given config. The config itself is not modified. Every set and
+// unset step of the inverse patch carries the value written by p as its
+// expected old value, so a rollback fails if the config was changed in the
+// meantime.
+func (p Patch) Inverse(c *Config) (Patch, error) {
+	return p.applyTo(c.Copy())
+}
+
+func (p Patch) applyTo(c *Config) (Patch, error) {
+	result := c.Copy()
+	inverse := Patch{}
+	for i, step := range p {
+		undo, err := step.inverse(result)
+		if err != nil {
+			return nil, PatchError{i, step, err}
+		}
+		step.apply(result)
+		inverse = append(undo, inverse...)
+	}
+	for section := range *c {
+		delete(*c, section)
+	}
+	for section, properties := range *result {
+		(*c)[section] = properties
+	}
+	return inverse, nil
+}
+
+// Return a patch which turns the config a into the config b. The steps are
+// sorted by section and property name and carry the values of a as expected
+// old values.
+func Diff(a, b *Config) Patch {
+	patch := Patch{}
+	sections := a.GetSections()
+	for _, section := range b.GetSections() {
+		if !a.HasSection(section) {
+			sections = append(sections, section)
+		}
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		if !b.HasSection(section) {
+			patch = append(patch, PatchStep{Operation: PatchRemoveSection, Section: section})
+			continue
+		}
+		if !a.HasSection(section) {
+			patch = append(patch, sectionPatch(b, section)...)
+			continue
+		}
+		oldProperties, newProperties := (*a)[section], (*b)[section]
+		for _, property := range sortedKeys(oldProperties) {
+			if _, exists := newProperties[property]; !exists {
+				patch = append(patch, PatchStep{Operation: PatchUnset, Section: section,
+					Property: property, OldValue: oldProperties[property], CheckOldValue: true})
+			}
+		}
+		for _, property := range sortedKeys(newProperties) {
+			newValue := newProperties[property]
+			oldValue, exists := oldProperties[property]
+			if !exists {
+				patch = append(patch, PatchStep{Operation: PatchSet, Section: section,
+					Property: property, Value: newValue})
+			} else if oldValue != newValue {
+				patch = append(patch, PatchStep{Operation: PatchSet, Section: section,
+					Property: property, Value: newValue, OldValue: oldValue, CheckOldValue: true})
+			}
+		}
+	}
+	return patch
+}
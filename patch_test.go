@@ -0,0 +1,182 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPatchStringRoundTrip(t *testing.T) {
+	patch := Patch{
+		{Operation: PatchAddSection, Section: "new section"},
+		{Operation: PatchSet, Section: "new section", Property: "a", Value: "x \"y\"\n"},
+		{Operation: PatchSet, Section: "s", Property: "b", Value: "2", OldValue: "1", CheckOldValue: true},
+		{Operation: PatchUnset, Section: "s", Property: "c"},
+		{Operation: PatchRemoveSection, Section: "old"},
+		{Operation: PatchRenameSection, Section: "from", Value: "to"}}
+	parsed, err := ParsePatch(patch.String())
+	assertErrorIsNil(err, t)
+	if !reflect.DeepEqual(parsed, patch) {
+		t.Errorf("expected %#v, got %#v", patch, parsed)
+	}
+}
+
+func TestParsePatchCommentsAndBlankLines(t *testing.T) {
+	patch, err := ParsePatch("# rollout 42\n\n; another comment\nadd-section \"a\"\n")
+	assertErrorIsNil(err, t)
+	expected := Patch{{Operation: PatchAddSection, Section: "a"}}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %#v, got %#v", expected, patch)
+	}
+}
+
+func TestParsePatchSyntaxErrors(t *testing.T) {
+	inputs := []string{
+		`frobnicate "a"`,
+		`set "a" "b"`,
+		`add-section "a" from "b"`,
+		`set "a" "b "c"`}
+	for _, input := range inputs {
+		_, err := ParsePatch("\n" + input)
+		var syntaxError PatchSyntaxError
+		if !errors.As(err, &syntaxError) {
+			t.Errorf("%q: expected PatchSyntaxError, got %v", input, err)
+		} else if syntaxError.Line != 2 {
+			t.Errorf("%q: expected error in line 2, got %d", input, syntaxError.Line)
+		}
+	}
+}
+
+func TestPatchApply(t *testing.T) {
+	conf := &Config{
+		"keep":   {"a": "1", "b": "2"},
+		"remove": {"x": "y"},
+		"rename": {"k": "v"}}
+	patch := Patch{
+		{Operation: PatchSet, Section: "keep", Property: "a", Value: "10", OldValue: "1", CheckOldValue: true},
+		{Operation: PatchUnset, Section: "keep", Property: "b"},
+		{Operation: PatchRemoveSection, Section: "remove"},
+		{Operation: PatchRenameSection, Section: "rename", Value: "renamed"},
+		{Operation: PatchAddSection, Section: "added"},
+		{Operation: PatchSet, Section: "added", Property: "new", Value: "value"}}
+	err := patch.Apply(conf)
+	assertErrorIsNil(err, t)
+	expectedConf := &Config{
+		"keep":    {"a": "10"},
+		"renamed": {"k": "v"},
+		"added":   {"new": "value"}}
+	assertConfigMapsEqual(conf, expectedConf, t)
+}
+
+func TestPatchApplyIsAtomic(t *testing.T) {
+	conf := &Config{"section": {"a": "1"}}
+	patch := Patch{
+		{Operation: PatchSet, Section: "section", Property: "a", Value: "2"},
+		{Operation: PatchSet, Section: "missing", Property: "b", Value: "3"}}
+	err := patch.Apply(conf)
+	var patchError PatchError
+	if !errors.As(err, &patchError) || patchError.Index != 1 {
+		t.Fatalf("expected PatchError for step 1, got %v", err)
+	}
+	if !errors.Is(err, NoSectionError) {
+		t.Errorf("expected NoSectionError, got %v", patchError.Err)
+	}
+	assertConfigMapsEqual(conf, &Config{"section": {"a": "1"}}, t)
+}
+
+func TestPatchApplyOldValueMismatch(t *testing.T) {
+	conf := &Config{"section": {"a": "changed"}}
+	patch := Patch{{Operation: PatchSet, Section: "section", Property: "a",
+		Value: "2", OldValue: "1", CheckOldValue: true}}
+	err := patch.Apply(conf)
+	expectedError := PatchConflictError{"section", "a", "1", "changed"}
+	var conflict PatchConflictError
+	if !errors.As(err, &conflict) || conflict != expectedError {
+		t.Errorf("expected %#v, got %#v", expectedError, err)
+	}
+}
+
+func TestPatchApplyPreconditions(t *testing.T) {
+	conf := &Config{"a": {"p": "v"}, "b": make(map[string]string)}
+	cases := []struct {
+		step     PatchStep
+		expected error
+	}{
+		{PatchStep{Operation: PatchAddSection, Section: "a"}, DuplicateSectionError},
+		{PatchStep{Operation: PatchRemoveSection, Section: "c"}, NoSectionError},
+		{PatchStep{Operation: PatchRenameSection, Section: "a", Value: "b"}, DuplicateSectionError},
+		{PatchStep{Operation: PatchUnset, Section: "a", Property: "q"}, NoPropertyError{"q"}}}
+	for _, c := range cases {
+		err := Patch{c.step}.Apply(conf)
+		if !errors.Is(err, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.step, c.expected, err)
+		}
+	}
+}
+
+func TestPatchInverse(t *testing.T) {
+	original := &Config{
+		"keep":   {"a": "1", "b": "2"},
+		"remove": {"x": "y", "z": "w"}}
+	conf := original.Copy()
+	patch := Patch{
+		{Operation: PatchSet, Section: "keep", Property: "a", Value: "10"},
+		{Operation: PatchSet, Section: "keep", Property: "c", Value: "3"},
+		{Operation: PatchUnset, Section: "keep", Property: "b"},
+		{Operation: PatchRemoveSection, Section: "remove"},
+		{Operation: PatchAddSection, Section: "added"},
+		{Operation: PatchSet, Section: "added", Property: "p", Value: "q"},
+		{Operation: PatchRenameSection, Section: "added", Value: "renamed"}}
+	inverse, err := patch.Inverse(conf)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, original, t)
+	assertErrorIsNil(patch.Apply(conf), t)
+	assertErrorIsNil(inverse.Apply(conf), t)
+	assertConfigMapsEqual(conf, original, t)
+}
+
+func TestPatchInverseDetectsLaterChanges(t *testing.T) {
+	conf := &Config{"section": {"a": "1"}}
+	patch := Patch{{Operation: PatchSet, Section: "section", Property: "a", Value: "2"}}
+	inverse, err := patch.Inverse(conf)
+	assertErrorIsNil(err, t)
+	assertErrorIsNil(patch.Apply(conf), t)
+	assertErrorIsNil(conf.Set("section", "a", "3"), t)
+	err = inverse.Apply(conf)
+	var conflict PatchConflictError
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected PatchConflictError, got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := &Config{
+		"same":    {"a": "1"},
+		"changed": {"a": "1", "b": "2", "c": "3"},
+		"removed": {"x": "y"}}
+	b := &Config{
+		"same":    {"a": "1"},
+		"changed": {"a": "1", "b": "20", "d": "4"},
+		"added":   {"p": "q"}}
+	patch := Diff(a, b)
+	expected := Patch{
+		{Operation: PatchAddSection, Section: "added"},
+		{Operation: PatchSet, Section: "added", Property: "p", Value: "q"},
+		{Operation: PatchUnset, Section: "changed", Property: "c", OldValue: "3", CheckOldValue: true},
+		{Operation: PatchSet, Section: "changed", Property: "b", Value: "20", OldValue: "2", CheckOldValue: true},
+		{Operation: PatchSet, Section: "changed", Property: "d", Value: "4"},
+		{Operation: PatchRemoveSection, Section: "removed"}}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected\n%s\ngot\n%s", expected, patch)
+	}
+	assertErrorIsNil(patch.Apply(a), t)
+	assertConfigMapsEqual(a, b, t)
+}
+
+func TestConfigCopy(t *testing.T) {
+	conf := &Config{"section": {"a": "1"}}
+	copied := conf.Copy()
+	copied.Set("section", "a", "2")
+	copied.AddSection("other")
+	assertConfigMapsEqual(conf, &Config{"section": {"a": "1"}}, t)
+}
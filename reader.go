@@ -14,16 +14,8 @@ func (c *Config) HasSection(section string) bool {
 // Returns true if a) the given section exists and b) the given property can be
 // found within the section. Otherwise false is returned.
 func (c *Config) HasProperty(section, property string) bool {
-	items, err := c.GetItems(section)
-	if err != nil {
-		return false
-	}
-	for _, item := range items {
-		if item.Property == property {
-			return true
-		}
-	}
-	return false
+	_, exists := (*c)[section][property]
+	return exists
 }
 
 // Returns a list of all section names of the config.
@@ -54,16 +46,15 @@ func (c *Config) GetItems(section string) (items []*Item, err error) {
 // does not exist, NoSectionError is returned. If the property does not exist
 // in the given section, NoPropertyError is returned.
 func (c *Config) Get(section, property string) (value string, err error) {
-	items, err := c.GetItems(section)
-	if err != nil {
-		return value, err
+	properties, exists := (*c)[section]
+	if !exists {
+		return value, NoSectionError
 	}
-	for _, item := range items {
-		if (*item).Property == property {
-			return (*item).Value, nil
-		}
+	value, exists = properties[property]
+	if !exists {
+		return value, NoPropertyError{property}
 	}
-	return value, NoPropertyError{property}
+	return value, nil
 }
 
 // Get the value of the passed property in the given section. If either the
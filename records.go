@@ -0,0 +1,124 @@
+package ini
+
+import "reflect"
+
+// Return all declarations of the given section in order, e.g. the blocks of a
+// list of backends, each declared as [backend] or [[backend]]. The sections
+// can be read and changed with their Get and Set methods. If the section does
+// not exist, an empty slice is returned.
+func (d *Document) Records(section string) []*DocumentSection {
+	records := d.sections(section)
+	if records == nil {
+		return []*DocumentSection{}
+	}
+	return records
+}
+
+// Append a new declaration of the given section to the document and return
+// it. If the last declaration of the section has the form [[name]], so has
+// the new one.
+func (d *Document) AppendRecord(section string) *DocumentSection {
+	record := &DocumentSection{Name: section, options: d.parseOptions()}
+	if records := d.sections(section); len(records) > 0 {
+		record.ArraySyntax = records[len(records)-1].ArraySyntax
+	}
+	d.Sections = append(d.Sections, record)
+	return record
+}
+
+// Remove the declaration of the section with the given index, counting from
+// 0, together with its properties and comments. If there is no such
+// declaration, NoRecordError is returned.
+func (d *Document) RemoveRecord(section string, index int) error {
+	records := d.sections(section)
+	if index < 0 || index >= len(records) {
+		return NoRecordError{section, index}
+	}
+	for i, s := range d.Sections {
+		if s == records[index] {
+			d.Sections = append(d.Sections[:i], d.Sections[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Decode every declaration of the given section into an element of the slice
+// v points to, see Config.Decode. The elements can be structs, pointers to
+// structs or maps. If v is not a pointer to a slice,
+// InvalidDecodeTargetError is returned.
+func (d *Document) DecodeRecords(section string, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Kind() != reflect.Slice {
+		return InvalidDecodeTargetError
+	}
+	slice := target.Elem()
+	records := d.sections(section)
+	decoded := reflect.MakeSlice(slice.Type(), len(records), len(records))
+	for i, record := range records {
+		conf := NewConfig()
+		conf.AddSection(section)
+		for _, property := range record.Properties {
+			conf.applyOperator(section, &Item{property.Name, property.Value}, property.Operator, d.parseOptions())
+		}
+		if err := (decoder{conf, DefaultSectionSeparator}).section(section, decoded.Index(i)); err != nil {
+			return err
+		}
+	}
+	slice.Set(decoded)
+	return nil
+}
+
+// return the options of the document the section belongs to
+func (s *DocumentSection) parseOptions() *ParseOptions {
+	if s.options == nil {
+		return defaultParseOptions
+	}
+	return s.options
+}
+
+// Get the value of the passed property in this declaration of the section
+// only. Names are compared with the case folding of the document. If the
+// property is assigned several times, the last value is returned; values
+// appended with += are joined with the list separator, just like in a
+// Config. If it is not assigned, NoPropertyError is returned.
+func (s *DocumentSection) Get(property string) (string, error) {
+	options := s.parseOptions()
+	value, found := "", false
+	for _, p := range s.Properties {
+		if !options.CaseFolding.Equal(p.Name, property) {
+			continue
+		}
+		switch {
+		case p.Operator == OperatorUnset:
+			value, found = "", false
+		case p.Operator == OperatorAppend && found:
+			value += listSeparator(options) + p.Value
+		default:
+			value, found = p.Value, true
+		}
+	}
+	if !found {
+		return "", NoPropertyError{property}
+	}
+	return value, nil
+}
+
+// Set the given property in this declaration of the section. If the property
+// is assigned several times, the last assignment is changed to an assignment
+// with =; if it is not assigned yet, it is appended to the section. Names are
+// compared with the case folding of the document.
+func (s *DocumentSection) Set(property, value string) {
+	folding := s.parseOptions().CaseFolding
+	var last *DocumentProperty
+	for _, p := range s.Properties {
+		if folding.Equal(p.Name, property) {
+			last = p
+		}
+	}
+	if last == nil {
+		s.Properties = append(s.Properties, &DocumentProperty{Name: property, Value: value})
+		return
+	}
+	last.assign(value)
+}
@@ -0,0 +1,166 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var recordOptions = &ParseOptions{SectionArrays: true, DuplicateProperties: DuplicateError}
+
+const recordDocument = `[balancer]
+strategy = round-robin
+
+[[backend]]
+host = a.example.com
+port = 8080
+
+# the fallback
+[[backend]]
+host = b.example.com
+weight = 2
+`
+
+type backend struct {
+	Host   string
+	Port   int
+	Weight int
+}
+
+func TestRecords(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(recordDocument), recordOptions)
+	assertErrorIsNil(err, t)
+	expectDocumentString(recordDocument, doc, t)
+	records := doc.Records("backend")
+	if len(records) != 2 {
+		t.Fatalf("expected two records, got %d", len(records))
+	}
+	host, err := records[1].Get("host")
+	assertErrorIsNil(err, t)
+	expectValue("b.example.com", host, t)
+	if _, err := records[1].Get("port"); err != (NoPropertyError{"port"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+	records[0].Set("port", "9090")
+	record := doc.AppendRecord("backend")
+	record.Set("host", "c.example.com")
+	assertErrorIsNil(doc.RemoveRecord("backend", 1), t)
+	if err := doc.RemoveRecord("backend", 2); err != (NoRecordError{"backend", 2}) {
+		t.Errorf("expected NoRecordError, got %v", err)
+	}
+	expected := `[balancer]
+strategy = round-robin
+
+[[backend]]
+host = a.example.com
+port = 9090
+[[backend]]
+host = c.example.com
+`
+	expectDocumentString(expected, doc, t)
+	expectValues([]string{"balancer", "backend"}, doc.GetSections(), t)
+	if len(doc.Records("missing")) != 0 {
+		t.Error("expected no records of a missing section")
+	}
+}
+
+func TestDecodeRecords(t *testing.T) {
+	doc, _ := ParseDocument(strings.NewReader(recordDocument), recordOptions)
+	var backends []backend
+	assertErrorIsNil(doc.DecodeRecords("backend", &backends), t)
+	expected := []backend{{"a.example.com", 8080, 0}, {"b.example.com", 0, 2}}
+	if !reflect.DeepEqual(backends, expected) {
+		t.Errorf("expected %+v, got %+v", expected, backends)
+	}
+	var maps []map[string]string
+	assertErrorIsNil(doc.DecodeRecords("balancer", &maps), t)
+	if len(maps) != 1 || maps[0]["strategy"] != "round-robin" {
+		t.Errorf("unexpected records %v", maps)
+	}
+	if err := doc.DecodeRecords("backend", &backend{}); err != InvalidDecodeTargetError {
+		t.Errorf("expected InvalidDecodeTargetError, got %v", err)
+	}
+}
+
+func TestRepeatedSectionsAsRecords(t *testing.T) {
+	input := "[backend]\nhost = a\n[backend]\nhost = b\n"
+	options := &ParseOptions{DuplicateSections: DuplicateKeepAll, DuplicateProperties: DuplicateError}
+	doc, err := ParseDocument(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	var backends []*backend
+	assertErrorIsNil(doc.DecodeRecords("backend", &backends), t)
+	if len(backends) != 2 || backends[0].Host != "a" || backends[1].Host != "b" {
+		t.Errorf("unexpected records %+v", backends)
+	}
+	_, err = ParseDocument(strings.NewReader(input), &ParseOptions{DuplicateProperties: DuplicateError})
+	if err != (DuplicateDefinitionError{"backend", "host", 2, 4}) {
+		t.Errorf("expected DuplicateDefinitionError, got %v", err)
+	}
+}
+
+func TestParseConfigSectionArrays(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(recordDocument), recordOptions)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{
+		"balancer": {"strategy": "round-robin"},
+		"backend":  {"host": "b.example.com", "port": "8080", "weight": "2"}}, t)
+	options := &ParseOptions{SectionArrays: true, DuplicateSections: DuplicateError}
+	_, err = ParseConfig(strings.NewReader("[[a]]\n[[a]]\n[a]\n"), options)
+	if err != (DuplicateDefinitionError{"a", "", 1, 3}) {
+		t.Errorf("expected DuplicateDefinitionError, got %v", err)
+	}
+	conf, err = ParseConfig(strings.NewReader("[[a]]\nx = 1\n"), nil)
+	assertErrorIsNil(err, t)
+	if !conf.HasSection("a") {
+		t.Errorf("expected the brackets to be trimmed without the option, got %v", conf)
+	}
+}
+
+func TestRecordOptions(t *testing.T) {
+	options := &ParseOptions{SectionArrays: true, Operators: true, CaseFolding: FoldASCII}
+	doc, err := ParseDocument(strings.NewReader("[[Backend]]\nHosts = a\nhosts += b\n"), options)
+	assertErrorIsNil(err, t)
+	record := doc.Records("backend")[0]
+	hosts, err := record.Get("HOSTS")
+	assertErrorIsNil(err, t)
+	expectValue("a, b", hosts, t)
+	record.Set("hosts", "c")
+	expectDocumentString("[[Backend]]\nHosts = a\nhosts = c\n", doc, t)
+	record = doc.AppendRecord("backend")
+	record.Set("Hosts", "d")
+	record.Set("HOSTS", "e")
+	hosts, err = record.Get("hosts")
+	assertErrorIsNil(err, t)
+	expectValue("e", hosts, t)
+	if len(record.Properties) != 1 {
+		t.Errorf("expected the property to be set once, got %d assignments", len(record.Properties))
+	}
+}
+
+func TestRecordsLastWins(t *testing.T) {
+	cases := []struct {
+		input   string
+		options *ParseOptions
+	}{
+		{"[[b]]\nx = 1\n[[b]]\nx = 2\nx = 3\n", &ParseOptions{SectionArrays: true, DuplicateProperties: DuplicateLastWins}},
+		{"[b]\nx = 1\n[b]\nx = 2\nx = 3\n", &ParseOptions{DuplicateSections: DuplicateKeepAll, DuplicateProperties: DuplicateLastWins}}}
+	for _, c := range cases {
+		doc, err := ParseDocument(strings.NewReader(c.input), c.options)
+		assertErrorIsNil(err, t)
+		records := doc.Records("b")
+		if len(records) != 2 {
+			t.Fatalf("expected two records, got %d", len(records))
+		}
+		for i, expected := range []string{"1", "3"} {
+			value, err := records[i].Get("x")
+			assertErrorIsNil(err, t)
+			expectValue(expected, value, t)
+			if len(records[i].Properties) != 1 {
+				t.Errorf("expected one assignment in record %d, got %d", i, len(records[i].Properties))
+			}
+		}
+	}
+	doc, err := ParseDocument(strings.NewReader("[b]\nx = 1\n[b]\nx = 2\n"), &ParseOptions{DuplicateProperties: DuplicateLastWins})
+	assertErrorIsNil(err, t)
+	expectDocumentString("[b]\n[b]\nx = 2\n", doc, t)
+}
@@ -0,0 +1,115 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// Return the error of parsing the source with the given name as a
+// SourceError. If the name is empty, the error is returned as it is.
+func (r *lineReader) sourceError(source string, err error) error {
+	if err == nil || source == "" {
+		return err
+	}
+	sourceError := SourceError{Source: source, Err: err}
+	if e, ok := err.(ParseError); ok {
+		sourceError.Line, sourceError.Err = e.Line, e.Err
+	} else if !r.eof {
+		sourceError.Line = r.lines
+	}
+	return sourceError
+}
+
+// Parse the source with the given name to a *Config. All constructors of a
+// Config use this function. If options is nil, the default options are used.
+func parseConfigSource(source string, reader io.ByteReader, options *ParseOptions) (*Config, error) {
+	if options == nil {
+		options = defaultParseOptions
+	}
+	lines := newLineReader(reader)
+	conf, err := parseINI(lines, options)
+	return conf, lines.sourceError(source, err)
+}
+
+// Parse the source with the given name to a *Document, see
+// parseConfigSource.
+func parseDocumentSource(source string, reader io.ByteReader, options *ParseOptions) (*Document, error) {
+	if options == nil {
+		options = defaultParseOptions
+	}
+	lines := newLineReader(reader)
+	doc, err := parseDocument(lines, options)
+	return doc, lines.sourceError(source, err)
+}
+
+// Create a new *Config from a Reader. The reader is read through a buffer,
+// see ParseConfigFromReader for parsing it with options.
+func NewConfigFromReader(reader io.Reader) (*Config, error) {
+	return ParseConfigFromReader(reader, nil)
+}
+
+// Create a new *Config from a Reader using the given options, see
+// ParseConfig. The reader is read through a buffer.
+func ParseConfigFromReader(reader io.Reader, options *ParseOptions) (*Config, error) {
+	return parseConfigSource("", bufio.NewReader(reader), options)
+}
+
+// Create a new *Config from the given bytes.
+func NewConfigFromBytes(data []byte) (*Config, error) {
+	return ParseConfigFromBytes(data, nil)
+}
+
+// Create a new *Config from the given bytes using the given options, see
+// ParseConfig.
+func ParseConfigFromBytes(data []byte, options *ParseOptions) (*Config, error) {
+	return parseConfigSource("", bytes.NewReader(data), options)
+}
+
+// Create a new *Config from the file with the given name in fsys, e.g. an
+// embed.FS or os.DirFS, using the given options. If options is nil, the
+// default options are used. Errors of the parser are returned as a
+// SourceError with the name of the file.
+func LoadConfig(fsys fs.FS, name string, options *ParseOptions) (*Config, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return new(Config), err
+	}
+	defer file.Close()
+	return parseConfigSource(name, bufio.NewReader(file), options)
+}
+
+// Create a new *Document from a Reader. The reader is read through a buffer,
+// see ParseDocumentFromReader for parsing it with options.
+func NewDocumentFromReader(reader io.Reader) (*Document, error) {
+	return ParseDocumentFromReader(reader, nil)
+}
+
+// Create a new *Document from a Reader using the given options, see
+// ParseConfigFromReader.
+func ParseDocumentFromReader(reader io.Reader, options *ParseOptions) (*Document, error) {
+	return parseDocumentSource("", bufio.NewReader(reader), options)
+}
+
+// Create a new *Document from the given bytes.
+func NewDocumentFromBytes(data []byte) (*Document, error) {
+	return ParseDocumentFromBytes(data, nil)
+}
+
+// Create a new *Document from the given bytes using the given options, see
+// ParseDocument.
+func ParseDocumentFromBytes(data []byte, options *ParseOptions) (*Document, error) {
+	return parseDocumentSource("", bytes.NewReader(data), options)
+}
+
+// Create a new *Document from the file with the given name in fsys, see
+// LoadConfig.
+func LoadDocument(fsys fs.FS, name string, options *ParseOptions) (*Document, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return NewDocument(), err
+	}
+	defer file.Close()
+	return parseDocumentSource(name, bufio.NewReader(file), options)
+}
@@ -0,0 +1,94 @@
+package ini
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"conf/app.ini":     {Data: []byte("[server]\nhost = localhost\n")},
+	"conf/broken.ini":  {Data: []byte("[server]\nhost = localhost\nport\n")},
+	"conf/parents.ini": {Data: []byte("[a : missing]\n")}}
+
+func TestNewConfigFromReaderAndBytes(t *testing.T) {
+	input := "[server]\nhost = localhost\n"
+	expected := &Config{"server": {"host": "localhost"}}
+	conf, err := NewConfigFromReader(strings.NewReader(input))
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, expected, t)
+	conf, err = NewConfigFromBytes([]byte(input))
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, expected, t)
+	doc, err := NewDocumentFromReader(strings.NewReader(input))
+	assertErrorIsNil(err, t)
+	expectDocumentString(input, doc, t)
+	doc, err = NewDocumentFromBytes([]byte(input))
+	assertErrorIsNil(err, t)
+	expectDocumentString(input, doc, t)
+	if _, err := NewConfigFromBytes([]byte("port\n")); err != MissingEqualSignError {
+		t.Errorf("expected MissingEqualSignError, got %v", err)
+	}
+}
+
+func TestParseFromReaderAndBytes(t *testing.T) {
+	input := "[Server]\nport\n"
+	options := &ParseOptions{AllowNoValue: true, CaseFolding: FoldASCII}
+	expected := &Config{"Server": {"port": ""}}
+	conf, err := ParseConfigFromReader(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, expected, t)
+	conf, err = ParseConfigFromBytes([]byte(input), options)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, expected, t)
+	doc, err := ParseDocumentFromReader(strings.NewReader(input), options)
+	assertErrorIsNil(err, t)
+	if !doc.IsFlag("server", "port") {
+		t.Error("expected the options to be used")
+	}
+	doc, err = ParseDocumentFromBytes([]byte(input), options)
+	assertErrorIsNil(err, t)
+	if !doc.IsFlag("server", "port") {
+		t.Error("expected the options to be used")
+	}
+	if _, err := ParseConfigFromBytes([]byte(input), nil); err != MissingEqualSignError {
+		t.Errorf("expected MissingEqualSignError without options, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	conf, err := LoadConfig(testFS, "conf/app.ini", nil)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(conf, &Config{"server": {"host": "localhost"}}, t)
+	_, err = LoadConfig(testFS, "conf/broken.ini", nil)
+	if err != (SourceError{"conf/broken.ini", 3, MissingEqualSignError}) {
+		t.Errorf("expected SourceError, got %v", err)
+	}
+	if !errors.Is(err, MissingEqualSignError) || err.Error() != "conf/broken.ini:3: missing equal sign" {
+		t.Errorf("unexpected error %q", err)
+	}
+	_, err = LoadConfig(testFS, "conf/parents.ini", &ParseOptions{Inheritance: true})
+	if err != (SourceError{"conf/parents.ini", 0, MissingParentError{"a", "missing"}}) {
+		t.Errorf("expected SourceError without a line, got %v", err)
+	}
+	if _, err := LoadConfig(testFS, "missing.ini", nil); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestLoadDocument(t *testing.T) {
+	doc, err := LoadDocument(testFS, "conf/app.ini", nil)
+	assertErrorIsNil(err, t)
+	expectDocumentString("[server]\nhost = localhost\n", doc, t)
+	_, err = LoadDocument(testFS, "conf/broken.ini", nil)
+	if err != (SourceError{"conf/broken.ini", 3, MissingEqualSignError}) {
+		t.Errorf("expected SourceError, got %v", err)
+	}
+	doc, err = LoadDocument(testFS, "conf/broken.ini", &ParseOptions{AllowNoValue: true})
+	assertErrorIsNil(err, t)
+	if !doc.IsFlag("server", "port") {
+		t.Error("expected the options to be used")
+	}
+}
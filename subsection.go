@@ -0,0 +1,114 @@
+package ini
+
+import (
+	"sort"
+	"strings"
+)
+
+// Return the name of the section with the given subsection as it is written
+// in git configuration files, e.g. remote "origin". Quotes and backslashes in
+// the subsection are escaped with a backslash. If the subsection is empty,
+// the section is returned unchanged.
+func SubsectionName(section, subsection string) string {
+	if subsection == "" {
+		return section
+	}
+	var buf strings.Builder
+	buf.WriteString(section)
+	buf.WriteString(` "`)
+	for i := 0; i < len(subsection); i++ {
+		if subsection[i] == '"' || subsection[i] == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(subsection[i])
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// Split a section name like remote "origin" into the section and the unquoted
+// subsection. Within the quotes, a backslash escapes the following character.
+// Names without quotes are returned unchanged with an empty subsection. If
+// the section name is missing, a quote is not closed or there are characters
+// after the closing quote, SubsectionSyntaxError is returned.
+func SplitSubsection(name string) (section, subsection string, err error) {
+	start := strings.IndexByte(name, '"')
+	if start < 0 {
+		return name, "", nil
+	}
+	section = strings.TrimSpace(name[:start])
+	if section == "" {
+		return "", "", SubsectionSyntaxError{name, "missing section name"}
+	}
+	var buf strings.Builder
+	rest := name[start+1:]
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+			if i == len(rest) {
+				return "", "", SubsectionSyntaxError{name, "missing closing quote"}
+			}
+			buf.WriteByte(rest[i])
+		case '"':
+			if strings.TrimSpace(rest[i+1:]) != "" {
+				return "", "", SubsectionSyntaxError{name, "unexpected characters after the subsection"}
+			}
+			return section, buf.String(), nil
+		default:
+			buf.WriteByte(rest[i])
+		}
+	}
+	return "", "", SubsectionSyntaxError{name, "missing closing quote"}
+}
+
+// Get the value of the passed property in the given subsection of the
+// section, see SubsectionName. The errors are the same as for Get.
+func (c *Config) GetIn(section, subsection, property string) (string, error) {
+	return c.Get(SubsectionName(section, subsection), property)
+}
+
+// Set the given property in the given subsection of the section, see
+// SubsectionName. The errors are the same as for Set.
+func (c *Config) SetIn(section, subsection, property, value string) error {
+	return c.Set(SubsectionName(section, subsection), property, value)
+}
+
+// Return the names of all subsections of the given section in sorted order,
+// e.g. origin and upstream for the section remote.
+func (c *Config) SubsectionsOf(section string) []string {
+	subsections := []string{}
+	for name := range *c {
+		s, subsection, err := SplitSubsection(name)
+		if err == nil && subsection != "" && s == section {
+			subsections = append(subsections, subsection)
+		}
+	}
+	sort.Strings(subsections)
+	return subsections
+}
+
+// Get the value of the passed property in the given subsection of the
+// section, see SubsectionName. The errors are the same as for Get.
+func (d *Document) GetIn(section, subsection, property string) (string, error) {
+	return d.Get(SubsectionName(section, subsection), property)
+}
+
+// Set the given property in the given subsection of the section, see
+// SubsectionName. The errors are the same as for Set.
+func (d *Document) SetIn(section, subsection, property, value string) error {
+	return d.Set(SubsectionName(section, subsection), property, value)
+}
+
+// Return the names of all subsections of the given section in the order of
+// their first appearance.
+func (d *Document) SubsectionsOf(section string) []string {
+	subsections := []string{}
+	for _, name := range d.GetSections() {
+		s, subsection, err := SplitSubsection(name)
+		if err == nil && subsection != "" && d.parseOptions().CaseFolding.Equal(s, section) {
+			subsections = append(subsections, subsection)
+		}
+	}
+	return subsections
+}
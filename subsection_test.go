@@ -0,0 +1,92 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+var subsectionOptions = &ParseOptions{Subsections: true}
+
+const gitConfig = `[core]
+	bare = false
+[remote   "origin"]
+	url = https://example.com/repo.git
+[remote "up\"stream"]
+	url = https://example.com/upstream.git
+[branch "main"]
+	remote = origin
+`
+
+func TestSubsectionName(t *testing.T) {
+	cases := []struct {
+		section, subsection, name string
+	}{
+		{"core", "", "core"},
+		{"remote", "origin", `remote "origin"`},
+		{"remote", `a"b\c`, `remote "a\"b\\c"`},
+		{"branch", "feature/x y", `branch "feature/x y"`}}
+	for _, c := range cases {
+		if name := SubsectionName(c.section, c.subsection); name != c.name {
+			t.Errorf("expected %q, got %q", c.name, name)
+		}
+		section, subsection, err := SplitSubsection(c.name)
+		assertErrorIsNil(err, t)
+		if section != c.section || subsection != c.subsection {
+			t.Errorf("%q: expected %q and %q, got %q and %q",
+				c.name, c.section, c.subsection, section, subsection)
+		}
+	}
+}
+
+func TestSplitSubsectionErrors(t *testing.T) {
+	for _, name := range []string{`"origin"`, `remote "origin`, `remote "origin\"`, `remote "a" b`} {
+		if _, _, err := SplitSubsection(name); err == nil {
+			t.Errorf("%q: expected a SubsectionSyntaxError", name)
+		}
+	}
+	section, subsection, err := SplitSubsection(`remote "a\b"`)
+	assertErrorIsNil(err, t)
+	if section != "remote" || subsection != "ab" {
+		t.Errorf("expected remote and ab, got %q and %q", section, subsection)
+	}
+}
+
+func TestParseConfigSubsections(t *testing.T) {
+	conf, err := ParseConfig(strings.NewReader(gitConfig), subsectionOptions)
+	assertErrorIsNil(err, t)
+	url, err := conf.GetIn("remote", "origin", "url")
+	assertErrorIsNil(err, t)
+	expectValue("https://example.com/repo.git", url, t)
+	expectValues([]string{"origin", `up"stream`}, conf.SubsectionsOf("remote"), t)
+	assertErrorIsNil(conf.SetIn("branch", "main", "merge", "refs/heads/main"), t)
+	if err := conf.SetIn("branch", "dev", "merge", "x"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	reparsed, err := ParseConfig(strings.NewReader(conf.String()), subsectionOptions)
+	assertErrorIsNil(err, t)
+	assertConfigMapsEqual(reparsed, conf, t)
+	_, err = ParseConfig(strings.NewReader("[remote \"origin]\n"), subsectionOptions)
+	if e, ok := err.(ParseError); !ok || e.Line != 1 {
+		t.Errorf("expected a ParseError in line 1, got %v", err)
+	} else if _, ok := e.Err.(SubsectionSyntaxError); !ok {
+		t.Errorf("expected SubsectionSyntaxError, got %v", e.Err)
+	}
+}
+
+func TestDocumentSubsections(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(gitConfig), subsectionOptions)
+	assertErrorIsNil(err, t)
+	expectDocumentString(gitConfig, doc, t)
+	expectValues([]string{"origin", `up"stream`}, doc.SubsectionsOf("remote"), t)
+	url, err := doc.GetIn("remote", `up"stream`, "url")
+	assertErrorIsNil(err, t)
+	expectValue("https://example.com/upstream.git", url, t)
+	assertErrorIsNil(doc.AddSection(SubsectionName("remote", `c:\repo`)), t)
+	assertErrorIsNil(doc.SetIn("remote", `c:\repo`, "url", "file:///c/repo"), t)
+	expected := gitConfig + "[remote \"c:\\\\repo\"]\nurl = file:///c/repo\n"
+	expectDocumentString(expected, doc, t)
+	_, err = ParseDocument(strings.NewReader("[core]\n[remote \"x\" y]\n"), subsectionOptions)
+	if err != (ParseError{2, SubsectionSyntaxError{`remote "x" y`, "unexpected characters after the subsection"}}) {
+		t.Errorf("expected a ParseError in line 2, got %v", err)
+	}
+}
@@ -1,5 +1,190 @@
 package ini
 
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the escape sequences for control characters written by escape
+var controlEscapes = map[rune]string{
+	0:    `\0`,
+	'\a': `\a`,
+	'\b': `\b`,
+	'\t': `\t`,
+	'\r': `\r`,
+	'\n': `\n`}
+
+// Escape the value so that the parser reads it back unchanged: backslashes,
+// equal signs and control characters are escaped, as is whitespace at the
+// beginning and the end, and hash signs and semicolons which could start a
+// comment. Bytes which are not valid UTF-8 are written as \xNN. If the file
+// has inline comments, i.e. prefixes is not empty, see
+// ParseOptions.InlineCommentPrefixes, double quotes and the first character
+// of every prefix which could begin a comment are escaped as well.
+func escape(text string, prefixes []string) string {
+	return escapeText(text, `\=`, "", prefixes)
+}
+
+// Escape the property name like a value, see escape. Additionally, an open
+// bracket or an exclamation mark at the beginning is escaped, so that the
+// line is neither read as a section header nor as !unset.
+func escapeProperty(name string, prefixes []string) string {
+	return escapeText(name, `\=`, "[!", prefixes)
+}
+
+// Escape the section name like a value, see escape, but with brackets,
+// colons and double quotes instead of equal signs. If the name has a
+// subsection like remote "origin", see SubsectionName, the quoted subsection
+// is written as it is.
+func escapeSectionName(name string, prefixes []string) string {
+	section, subsection, err := SplitSubsection(name)
+	if err == nil && subsection != "" && SubsectionName(section, subsection) == name && isPrintable(subsection) {
+		return escapeText(section, `\[]:"`, "", prefixes) + name[len(section):]
+	}
+	return escapeText(name, `\[]:"`, "", prefixes)
+}
+
+// Returns true if the text is valid UTF-8 without any control characters.
+func isPrintable(text string) bool {
+	if !utf8.ValidString(text) {
+		return false
+	}
+	for _, r := range text {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if a comment with one of the prefixes or the default markers
+// # and ; could begin at index i of the text.
+func startsComment(text string, i int, prefixes []string) bool {
+	if i > 0 && text[i-1] != ' ' {
+		return false
+	}
+	if text[i] == '#' || text[i] == ';' {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(text[i:], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if escapeText would change the text. Texts with characters
+// other than printable ASCII are always escaped.
+func needsEscape(text, special, leading string, prefixes []string) bool {
+	if text == "" {
+		return false
+	}
+	if text[0] == ' ' || text[len(text)-1] == ' ' || strings.IndexByte(leading, text[0]) >= 0 {
+		return true
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c < 0x20 || c >= 0x7f:
+			return true
+		case strings.IndexByte(special, c) >= 0:
+			return true
+		case c == '"' && len(prefixes) > 0:
+			return true
+		case startsComment(text, i, prefixes):
+			return true
+		}
+	}
+	return false
+}
+
+// Escape a character which has no escape sequence of its own, see
+// escapeSequences, as \xNN, \uNNNN or \UNNNNNNNN.
+func escapeRune(r rune) string {
+	switch {
+	case r < utf8.RuneSelf && escapeSequences[byte(r)] == string(r):
+		return `\` + string(r)
+	case r < utf8.RuneSelf:
+		return fmt.Sprintf(`\x%02x`, r)
+	case r <= 0xffff:
+		return fmt.Sprintf(`\u%04x`, r)
+	}
+	return fmt.Sprintf(`\U%08x`, r)
+}
+
+// Escape the text, see escape. The characters in special are always escaped,
+// those in leading only at the beginning of the text.
+func escapeText(text, special, leading string, prefixes []string) string {
+	if !needsEscape(text, special, leading, prefixes) {
+		return text
+	}
+	start := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	end := len(strings.TrimRightFunc(text, unicode.IsSpace))
+	var buf strings.Builder
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&buf, `\x%02x`, text[i])
+		case strings.ContainsRune(special, r) || (i == 0 && strings.ContainsRune(leading, r)):
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '"' && len(prefixes) > 0, startsComment(text, i, prefixes):
+			buf.WriteString(escapeRune(r))
+		case controlEscapes[r] != "":
+			buf.WriteString(controlEscapes[r])
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&buf, `\x%02x`, r)
+		case r == ' ' && (i < start || i >= end):
+			buf.WriteString(`\x20`)
+		case i < start || i >= end:
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		default:
+			buf.WriteString(text[i : i+size])
+		}
+		i += size
+	}
+	return buf.String()
+}
+
+// Check whether a section with the given name can be written to a file and
+// parsed again with the given options, which may be nil. Thanks to escape
+// sequences, this is true for all names except the empty one and, if
+// ParseOptions.Subsections is set, names with an invalid subsection. In these
+// cases, InvalidNameError or SubsectionSyntaxError is returned.
+func ValidateSectionName(name string, options *ParseOptions) error {
+	if name == "" {
+		return InvalidNameError{name, "the name is empty"}
+	}
+	if options != nil && options.Subsections {
+		if _, _, err := SplitSubsection(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check whether a property with the given name can be written to a file and
+// parsed again with the given options, which may be nil. Thanks to escape
+// sequences, this is true for all names except the empty one, names ending
+// with + if ParseOptions.Operators is set and names ending with [] if
+// ParseOptions.ArrayKeys is set. In these cases, InvalidNameError is
+// returned.
+func ValidatePropertyName(name string, options *ParseOptions) error {
+	switch {
+	case name == "":
+		return InvalidNameError{name, "the name is empty"}
+	case options != nil && options.Operators && strings.HasSuffix(name, "+"):
+		return InvalidNameError{name, "the name ends with the append operator"}
+	case options != nil && options.ArrayKeys && strings.HasSuffix(name, "[]"):
+		return InvalidNameError{name, "the name ends with []"}
+	}
+	return nil
+}
+
 // Add a new section to the config. If a section with this name already exists,
 // the error DuplicateSectionError is returned and the section won't be added.
 func (c *Config) AddSection(section string) error {
@@ -24,17 +209,15 @@ func (c *Config) RemoveSection(section string) error {
 // exists, NoSectionError will be returned. If the section exists but not the
 // property, NoPropertyError will be returned.
 func (c *Config) RemoveProperty(section, property string) error {
-	items, err := c.GetItems(section)
-	if err != nil {
-		return err
+	properties, exists := (*c)[section]
+	if !exists {
+		return NoSectionError
 	}
-	for _, item := range items {
-		if item.Property == property {
-			delete((*c)[section], item.Property)
-			return nil
-		}
+	if _, exists := properties[property]; !exists {
+		return NoPropertyError{property}
 	}
-	return NoPropertyError{property}
+	delete(properties, property)
+	return nil
 }
 
 // Set the given property in the given section to the passed value. Attempting
@@ -48,3 +231,66 @@ func (c *Config) Set(section, property, value string) error {
 	(*c)[section][property] = value
 	return nil
 }
+
+// Rename the given section, keeping its properties. If the section does not
+// exist, NoSectionError is returned. If a section with the new name already
+// exists, DuplicateSectionError is returned and nothing is changed.
+func (c *Config) RenameSection(section, newName string) error {
+	if !c.HasSection(section) {
+		return NoSectionError
+	}
+	if newName == section {
+		return nil
+	}
+	if c.HasSection(newName) {
+		return DuplicateSectionError
+	}
+	(*c)[newName] = (*c)[section]
+	delete(*c, section)
+	return nil
+}
+
+// Rename the given property of the passed section, keeping its value. If the
+// section does not exist, NoSectionError is returned; if the property does
+// not exist, NoPropertyError is returned. If the section already contains a
+// property with the new name, DuplicatePropertyError is returned and nothing
+// is changed.
+func (c *Config) RenameProperty(section, property, newName string) error {
+	value, err := c.Get(section, property)
+	if err != nil {
+		return err
+	}
+	if newName == property {
+		return nil
+	}
+	if c.HasProperty(section, newName) {
+		return DuplicatePropertyError{newName}
+	}
+	delete((*c)[section], property)
+	(*c)[section][newName] = value
+	return nil
+}
+
+// Move the given property with its value from one section to another. If one
+// of the sections does not exist, NoSectionError is returned; if the property
+// does not exist, NoPropertyError is returned. If the target section already
+// contains the property, DuplicatePropertyError is returned and nothing is
+// changed.
+func (c *Config) MoveProperty(section, property, target string) error {
+	value, err := c.Get(section, property)
+	if err != nil {
+		return err
+	}
+	if !c.HasSection(target) {
+		return NoSectionError
+	}
+	if target == section {
+		return nil
+	}
+	if c.HasProperty(target, property) {
+		return DuplicatePropertyError{property}
+	}
+	delete((*c)[section], property)
+	(*c)[target][property] = value
+	return nil
+}
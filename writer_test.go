@@ -86,3 +86,64 @@ func TestSetPropertyValueExistingSection(t *testing.T) {
 	expectedConf := &Config{"section": {"property": "value"}}
 	assertConfigMapsEqual(conf, expectedConf, t)
 }
+
+func TestRenameSection(t *testing.T) {
+	conf := &Config{"old": {"prop": "value"}, "other": make(map[string]string)}
+	assertErrorIsNil(conf.RenameSection("old", "new"), t)
+	assertConfigMapsEqual(conf, &Config{"new": {"prop": "value"}, "other": make(map[string]string)}, t)
+	if err := conf.RenameSection("old", "x"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	if err := conf.RenameSection("new", "other"); err != DuplicateSectionError {
+		t.Errorf("expected DuplicateSectionError, got %v", err)
+	}
+	assertErrorIsNil(conf.RenameSection("new", "new"), t)
+}
+
+func TestRenameProperty(t *testing.T) {
+	conf := &Config{"section": {"old": "1", "other": "2"}}
+	assertErrorIsNil(conf.RenameProperty("section", "old", "new"), t)
+	assertConfigMapsEqual(conf, &Config{"section": {"new": "1", "other": "2"}}, t)
+	if err := conf.RenameProperty("section", "old", "x"); err != (NoPropertyError{"old"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+	if err := conf.RenameProperty("section", "new", "other"); err != (DuplicatePropertyError{"other"}) {
+		t.Errorf("expected DuplicatePropertyError, got %v", err)
+	}
+	if err := conf.RenameProperty("missing", "new", "x"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+}
+
+func TestMoveProperty(t *testing.T) {
+	conf := &Config{"a": {"prop": "1", "other": "2"}, "b": {"other": "3"}}
+	assertErrorIsNil(conf.MoveProperty("a", "prop", "b"), t)
+	assertConfigMapsEqual(conf, &Config{"a": {"other": "2"}, "b": {"prop": "1", "other": "3"}}, t)
+	if err := conf.MoveProperty("a", "other", "b"); err != (DuplicatePropertyError{"other"}) {
+		t.Errorf("expected DuplicatePropertyError, got %v", err)
+	}
+	if err := conf.MoveProperty("a", "other", "missing"); err != NoSectionError {
+		t.Errorf("expected NoSectionError, got %v", err)
+	}
+	if err := conf.MoveProperty("a", "prop", "b"); err != (NoPropertyError{"prop"}) {
+		t.Errorf("expected NoPropertyError, got %v", err)
+	}
+}
+
+func TestValidateNames(t *testing.T) {
+	assertErrorIsNil(ValidateSectionName("[a]: b", nil), t)
+	assertErrorIsNil(ValidatePropertyName(" a+ = [] ", nil), t)
+	if err := ValidateSectionName("", nil); err != (InvalidNameError{"", "the name is empty"}) {
+		t.Errorf("expected InvalidNameError, got %v", err)
+	}
+	options := &ParseOptions{Subsections: true, Operators: true, ArrayKeys: true}
+	assertErrorIsNil(ValidateSectionName(`remote "origin"`, options), t)
+	if _, ok := ValidateSectionName(`remote "origin`, options).(SubsectionSyntaxError); !ok {
+		t.Error("expected SubsectionSyntaxError")
+	}
+	for _, name := range []string{"", "a+", "a[]"} {
+		if _, ok := ValidatePropertyName(name, options).(InvalidNameError); !ok {
+			t.Errorf("expected InvalidNameError for %q", name)
+		}
+	}
+}